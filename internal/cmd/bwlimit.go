@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gowsp/cloud189/pkg"
+	"github.com/spf13/cobra"
+)
+
+// bwlimitValue sets the shared bandwidth limit once the flag is parsed
+type bwlimitValue struct{}
+
+func (bwlimitValue) String() string        { return pkg.Bandwidth.String() }
+func (bwlimitValue) Set(spec string) error { return pkg.Bandwidth.Set(spec) }
+func (bwlimitValue) Type() string          { return "limit" }
+
+var bwlimitCmd = &cobra.Command{
+	Use:   "bwlimit",
+	Short: "show or change bandwidth limit of transfers, arg: rate like 2M, off or schedule like '08:00,1M 20:00,off'",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 {
+			// the shell splits schedule by space and keeps quotes
+			spec := strings.Trim(strings.Join(args, " "), `'"`)
+			if err := pkg.Bandwidth.Set(spec); err != nil {
+				printError(err)
+				return
+			}
+		}
+		fmt.Println("bandwidth limit:", pkg.Bandwidth)
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{upCmd, dlCmd, webdavCmd, shareCmd, webCmd} {
+		cmd.Flags().Var(bwlimitValue{}, "bwlimit", "bandwidth limit such as 2M, or daily schedule like '08:00,1M 20:00,off'")
+	}
+}
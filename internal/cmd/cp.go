@@ -1,13 +1,13 @@
 package cmd
 
 import (
-	"fmt"
-
 	"github.com/gowsp/cloud189/internal/session"
 	"github.com/gowsp/cloud189/pkg/file"
 	"github.com/spf13/cobra"
 )
 
+var cpTo string
+
 var cpCmd = &cobra.Command{
 	Use:    "cp",
 	Short:  "copy file",
@@ -16,14 +16,33 @@ var cpCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		err := file.CheckPath(args...)
 		if err != nil {
-			fmt.Println(err)
+			printError(err)
+			return
+		}
+		cfg, err := taskConfig()
+		if err != nil {
+			printError(err)
 			return
 		}
 		length := len(args)
 		dest := args[length-1]
 		from := args[:length-1]
-		if err = App().Copy(dest, from...); err != nil {
-			fmt.Println(err)
+		if cpTo != "" {
+			to, err := Space(cpTo)
+			if err != nil {
+				printError(err)
+				return
+			}
+			err = App().CopyTo(cfg, to, dest, from...)
+		} else {
+			err = App().Copy(cfg, dest, from...)
+		}
+		if err != nil {
+			printError(err)
 		}
 	},
 }
+
+func init() {
+	cpCmd.Flags().StringVar(&cpTo, "to", "", "space of target dir, personal or family[:id], copy between personal and family cloud")
+}
@@ -13,7 +13,7 @@ var dfCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		space, err := App().Space()
 		if err != nil {
-			fmt.Println(err)
+			printError(err)
 			return
 		}
 		capacity := space.Capacity
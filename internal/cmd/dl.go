@@ -1,11 +1,12 @@
 package cmd
 
 import (
-	"fmt"
 	"log"
 
 	"github.com/gowsp/cloud189/internal/session"
+	"github.com/gowsp/cloud189/pkg"
 	"github.com/gowsp/cloud189/pkg/file"
+	"github.com/gowsp/cloud189/pkg/share"
 	"github.com/spf13/cobra"
 )
 
@@ -15,16 +16,47 @@ var dlCmd = &cobra.Command{
 	Args:  cobra.MinimumNArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
 		length := len(args)
-		clouds := args[:length-1]
+		local := args[length-1]
+		var clouds []string
+		for _, arg := range args[:length-1] {
+			if share.IsShare(arg) {
+				downloadShare(local, arg)
+				continue
+			}
+			clouds = append(clouds, arg)
+		}
+		if len(clouds) == 0 {
+			return
+		}
 		session.Parse(cmd, clouds)
 		err := file.CheckPath(clouds...)
 		if err != nil {
-			fmt.Println(err)
+			printError(err)
 			return
 		}
-		local := args[length-1]
-		if err := App().Download(local, clouds...); err != nil {
+		if err := App().Download(pkg.DownloadConfig{Progress: newProgress()}, local, clouds...); err != nil {
 			log.Println(err)
 		}
 	},
 }
+
+// downloadShare downloads from share link without login
+func downloadShare(local, name string) {
+	code, path := share.Split(name)
+	d, info, err := share.Open(code, shareCode)
+	if err != nil {
+		log.Println(name, err)
+		return
+	}
+	if path == "/" && !info.IsDir {
+		path += info.Name
+	}
+	if err := d.Download(pkg.DownloadConfig{Progress: newProgress()}, local, path); err != nil {
+		log.Println(err)
+	}
+}
+
+func init() {
+	dlCmd.Flags().StringVarP(&shareCode, "code", "c", "", "access code of the share link")
+	dlCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "do not print progress")
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/gowsp/cloud189/pkg"
+	"github.com/spf13/cobra"
+)
+
+var familyCmd = &cobra.Command{
+	Use:   "family",
+	Short: "list family clouds, use with --space family:id",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		families, err := accountApi().Families()
+		if err != nil {
+			printError(err)
+			return
+		}
+		printFamilies(families)
+	},
+}
+
+func printFamilies(families []pkg.Family) {
+	for _, f := range families {
+		fmt.Printf("%-20s%s\n", f.Id, f.Name)
+	}
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/gowsp/cloud189/internal/session"
+	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/file"
+	"github.com/gowsp/cloud189/pkg/share"
+	"github.com/spf13/cobra"
+)
+
+var (
+	shareCode    string
+	importSelect []string
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "save files of share link into cloud dir, args: share url, cloud dir",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		target := session.Join(args[1])
+		if err := file.CheckPath(target); err != nil {
+			printError(err)
+			return
+		}
+		d, info, err := share.Open(args[0], shareCode)
+		if err != nil {
+			printError(err)
+			return
+		}
+		selected := importSelect
+		if len(selected) == 0 {
+			// save the whole share
+			if info.IsDir {
+				selected = []string{"/"}
+			} else {
+				selected = []string{"/" + info.Name}
+			}
+		}
+		files, err := shareFiles(d, selected)
+		if len(files) > 0 {
+			err = errors.Join(App().Import(info, target, files...), err)
+		}
+		if err != nil {
+			printError(err)
+		}
+	},
+}
+
+var shareLsCmd = &cobra.Command{
+	Use:   "share-ls",
+	Short: "list files of share link, args: share url, path in share",
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		d, info, err := share.Open(args[0], shareCode)
+		if err != nil {
+			printError(err)
+			return
+		}
+		name := "/"
+		if len(args) > 1 {
+			name = path.Join("/", args[1])
+		}
+		files, err := d.ReadDir(name)
+		if err != nil {
+			printError(err)
+			return
+		}
+		fmt.Printf("share %s: %s\n", info.Id, info.Name)
+		for _, v := range files {
+			info, _ := v.Info()
+			fmt.Println(file.ReadableFileInfo(info))
+		}
+	},
+}
+
+// shareFiles resolves paths in share, wildcard is supported
+func shareFiles(d pkg.Drive, paths []string) (files []pkg.File, err error) {
+	var errs []error
+	for _, name := range paths {
+		name = path.Join("/", name)
+		matches, err := d.Glob(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if len(matches) == 0 {
+			errs = append(errs, fmt.Errorf("%s: no such file in share", name))
+			continue
+		}
+		for _, match := range matches {
+			info, err := d.Stat(match)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			files = append(files, info.(pkg.File))
+		}
+	}
+	return files, errors.Join(errs...)
+}
+
+func init() {
+	importCmd.Flags().StringVarP(&shareCode, "code", "c", "", "access code of the share link")
+	importCmd.Flags().StringArrayVarP(&importSelect, "select", "s", nil, "path in share to save, supports wildcard, default whole share")
+	shareLsCmd.Flags().StringVarP(&shareCode, "code", "c", "", "access code of the share link")
+}
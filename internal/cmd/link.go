@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gowsp/cloud189/internal/session"
+	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/file"
+	"github.com/spf13/cobra"
+)
+
+var (
+	linkExpire string
+	linkCode   bool
+)
+
+var linkCmd = &cobra.Command{
+	Use:   "link",
+	Short: "manage official share links",
+}
+
+var linkCreateCmd = &cobra.Command{
+	Use:    "create",
+	Short:  "create share link, arg: cloud path",
+	PreRun: session.Parse,
+	Args:   cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := file.CheckPath(args...)
+		if err != nil {
+			printError(err)
+			return
+		}
+		days, err := parseExpire(linkExpire)
+		if err != nil {
+			printError(err)
+			return
+		}
+		for _, arg := range args {
+			link, err := App().CreateLink(arg, days, linkCode)
+			if err != nil {
+				fmt.Println(arg, err)
+				continue
+			}
+			printLink(link)
+		}
+	},
+}
+
+var linkLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "list share links",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		links, err := App().Links()
+		if err != nil {
+			printError(err)
+			return
+		}
+		for i := range links {
+			printLink(&links[i])
+		}
+	},
+}
+
+var linkRmCmd = &cobra.Command{
+	Use:   "rm",
+	Short: "cancel share links, arg: share id",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := App().CancelLink(args...); err != nil {
+			printError(err)
+		}
+	},
+}
+
+// parseExpire parses expire like 1d, 7d, 30d, 0 or never, returns days
+func parseExpire(expire string) (int, error) {
+	switch expire {
+	case "", "0", "never":
+		return 0, nil
+	}
+	days, err := strconv.Atoi(strings.TrimSuffix(expire, "d"))
+	if err != nil || days < 0 {
+		return 0, fmt.Errorf("invalid expire %s, example: 7d", expire)
+	}
+	return days, nil
+}
+
+func printLink(link *pkg.ShareLink) {
+	expire := "never"
+	if !link.ExpireTime.IsZero() {
+		expire = link.ExpireTime.Format("2006-01-02 15:04:05")
+	}
+	code := link.AccessCode
+	if code == "" {
+		code = "-"
+	}
+	fmt.Printf("%-20s%-8s%-22s%s %s\n", link.Id, code, expire, link.Url, link.Name)
+}
+
+func init() {
+	linkCreateCmd.Flags().StringVarP(&linkExpire, "expire", "e", "7d", "expire days of the link, 1d, 7d or never")
+	linkCreateCmd.Flags().BoolVarP(&linkCode, "code", "c", false, "create private link with access code")
+	linkCmd.AddCommand(linkCreateCmd, linkLsCmd, linkRmCmd)
+}
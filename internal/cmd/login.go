@@ -7,7 +7,10 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var usePwd bool
+var (
+	usePwd bool
+	helper string
+)
 
 var loginCmd = &cobra.Command{
 	Use:   "login",
@@ -44,6 +47,9 @@ var qrLoginCmd = &cobra.Command{
 }
 
 func loginFunc(username, password string) {
+	if helper != "" {
+		loadConfig().CredentialHelper = helper
+	}
 	if err := App().Login(username, password); err != nil {
 		fmt.Printf("\n%s\n", err)
 		return
@@ -53,4 +59,5 @@ func loginFunc(username, password string) {
 
 func init() {
 	loginCmd.Flags().BoolVarP(&usePwd, "i", "i", false, "input username and password to login")
+	loginCmd.Flags().StringVar(&helper, "credential-helper", "", "command keeping password in git-credential style, such as 'git credential-store'")
 }
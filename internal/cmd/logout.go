@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/gowsp/cloud189/pkg/invoker"
+	"github.com/gowsp/cloud189/pkg"
 	"github.com/peterh/liner"
 	"github.com/spf13/cobra"
 )
@@ -34,10 +34,11 @@ var logoutCmd = &cobra.Command{
 }
 
 func logout() error {
-	if cfgFile == "" {
-		cfgFile = invoker.DefaultPath()
+	// clear the selected profile, keep other profiles
+	err := accountApi().(pkg.DriveApi).Logout()
+	if err == nil && len(loadConfig().Profiles) == 0 {
+		err = os.Remove(cfgFile)
 	}
-	err := os.Remove(cfgFile)
 	if os.IsNotExist(err) {
 		err = nil
 	}
@@ -16,7 +16,7 @@ var lsCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		err := file.CheckPath(args...)
 		if err != nil {
-			fmt.Println(err)
+			printError(err)
 			return
 		}
 		var name string
@@ -28,7 +28,7 @@ var lsCmd = &cobra.Command{
 		client := App()
 		files, err := client.ReadDir(name)
 		if err != nil {
-			fmt.Println(err)
+			printError(err)
 			return
 		}
 		for _, v := range files {
@@ -16,7 +16,7 @@ var mkdirCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		err := file.CheckPath(args...)
 		if err != nil {
-			fmt.Println(err)
+			printError(err)
 			return
 		}
 		for _, arg := range args {
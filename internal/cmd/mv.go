@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"fmt"
-
 	"github.com/gowsp/cloud189/internal/session"
 	"github.com/gowsp/cloud189/pkg/file"
 	"github.com/spf13/cobra"
@@ -16,14 +14,19 @@ var mvCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		err := file.CheckPath(args...)
 		if err != nil {
-			fmt.Println(err)
+			printError(err)
+			return
+		}
+		cfg, err := taskConfig()
+		if err != nil {
+			printError(err)
 			return
 		}
 		length := len(args)
 		dest := args[length-1]
 		from := args[:length-1]
-		if err := App().Move(dest, from...); err != nil {
-			fmt.Println(err)
+		if err := App().Move(cfg, dest, from...); err != nil {
+			printError(err)
 		}
 	},
 }
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/gowsp/cloud189/pkg/invoker"
+	"github.com/spf13/cobra"
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "manage account profiles",
+}
+
+var profileAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "add profile, login with --profile name login",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := loadConfig().AddProfile(args[0]); err != nil {
+			printError(err)
+		}
+	},
+}
+
+var profileLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "list profiles, current profile marked with *",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf := loadConfig()
+		current := conf.Current
+		if current == "" {
+			current = invoker.DefaultProfile
+		}
+		for _, name := range conf.ProfileNames() {
+			mark := " "
+			if name == current {
+				mark = "*"
+			}
+			user := "-"
+			if p, _ := conf.Profile(name); p.Session != nil && p.Session.LoginName != "" {
+				user = p.Session.LoginName
+			}
+			fmt.Printf("%s %-20s%s\n", mark, name, user)
+		}
+	},
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use",
+	Short: "switch current profile",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := loadConfig().UseProfile(args[0]); err != nil {
+			printError(err)
+		}
+	},
+}
+
+var profileRmCmd = &cobra.Command{
+	Use:   "rm",
+	Short: "remove profile",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, name := range args {
+			if err := loadConfig().RemoveProfile(name); err != nil {
+				printError(err)
+			}
+		}
+	},
+}
+
+func init() {
+	profileCmd.AddCommand(profileAddCmd, profileLsCmd, profileUseCmd, profileRmCmd)
+}
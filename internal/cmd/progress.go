@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/file"
+)
+
+var quiet bool
+
+const barWidth = 30
+
+// progressBars renders a line per active transfer, finished ones are printed once above the bars
+type progressBars struct {
+	mu  sync.Mutex
+	out io.Writer
+	tty bool
+	// ids of active transfers in start order
+	ids    []uint64
+	active map[uint64]pkg.ProgressEvent
+	// lines drawn last time
+	drawn int
+}
+
+// newProgress returns nil in quiet mode
+func newProgress() pkg.Progress {
+	if quiet {
+		return nil
+	}
+	tty := false
+	if info, err := os.Stdout.Stat(); err == nil {
+		tty = info.Mode()&os.ModeCharDevice != 0
+	}
+	return &progressBars{out: os.Stdout, tty: tty, active: make(map[uint64]pkg.ProgressEvent)}
+}
+
+func (p *progressBars) OnProgress(e pkg.ProgressEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// same-named files of different dirs are told apart by id
+	key := e.Id
+	switch e.Kind {
+	case pkg.ProgressFinish, pkg.ProgressError:
+		p.clear()
+		p.remove(key)
+		fmt.Fprintln(p.out, summary(e))
+	default:
+		if _, ok := p.active[key]; !ok {
+			p.ids = append(p.ids, key)
+		}
+		p.active[key] = e
+		if !p.tty {
+			return
+		}
+		p.clear()
+	}
+	p.draw()
+}
+
+func (p *progressBars) remove(key uint64) {
+	delete(p.active, key)
+	for i, id := range p.ids {
+		if id == key {
+			p.ids = append(p.ids[:i], p.ids[i+1:]...)
+			break
+		}
+	}
+}
+
+// clear moves cursor to the first bar and erases the bars
+func (p *progressBars) clear() {
+	if !p.tty || p.drawn == 0 {
+		return
+	}
+	fmt.Fprintf(p.out, "\x1b[%dA\x1b[J", p.drawn)
+	p.drawn = 0
+}
+
+func (p *progressBars) draw() {
+	if !p.tty {
+		return
+	}
+	for _, id := range p.ids {
+		fmt.Fprintln(p.out, bar(p.active[id]))
+	}
+	p.drawn = len(p.ids)
+}
+
+func bar(e pkg.ProgressEvent) string {
+	percent := 0.0
+	if e.Total > 0 {
+		percent = min(float64(e.Done)/float64(e.Total), 1)
+	}
+	filled := int(percent * barWidth)
+	line := fmt.Sprintf("%-8s [%s%s] %5.1f%% %10s/s", e.Op,
+		strings.Repeat("=", filled), strings.Repeat(" ", barWidth-filled),
+		percent*100, file.ReadableSize(uint64(e.Speed)))
+	if e.Parts > 1 {
+		line += fmt.Sprintf(" part %d/%d", e.Part, e.Parts)
+	}
+	if e.ETA > 0 {
+		line += " eta " + e.ETA.Round(time.Second).String()
+	}
+	return line + " " + e.Name
+}
+
+func summary(e pkg.ProgressEvent) string {
+	if e.Kind == pkg.ProgressError {
+		return fmt.Sprintf("%-8s failed %s: %s", e.Op, e.Name, e.Error)
+	}
+	return fmt.Sprintf("%-8s done   %s %s", e.Op, e.Name, file.ReadableSize(uint64(e.Total)))
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gowsp/cloud189/pkg"
+)
+
+func TestProgressSameName(t *testing.T) {
+	var out bytes.Buffer
+	p := &progressBars{out: &out, active: make(map[uint64]pkg.ProgressEvent)}
+	a := pkg.NewTransfer(p, pkg.OpUpload, "a.txt", 10, 1)
+	b := pkg.NewTransfer(p, pkg.OpUpload, "a.txt", 20, 1)
+	a.Start(0)
+	b.Start(0)
+	if len(p.ids) != 2 {
+		t.Fatalf("bars of same-named files %v", p.ids)
+	}
+	a.Finish(nil)
+	if _, ok := p.active[b.Id()]; len(p.ids) != 1 || !ok {
+		t.Errorf("bar of the other file removed, active %v", p.ids)
+	}
+}
@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"fmt"
-
 	"github.com/gowsp/cloud189/internal/session"
 	"github.com/gowsp/cloud189/pkg/file"
 	"github.com/spf13/cobra"
@@ -16,11 +14,16 @@ var rmCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		err := file.CheckPath(args...)
 		if err != nil {
-			fmt.Println(err)
+			printError(err)
+			return
+		}
+		cfg, err := taskConfig()
+		if err != nil {
+			printError(err)
 			return
 		}
-		if err := App().Delete(args...); err != nil {
-			fmt.Println(err)
+		if err := App().Delete(cfg, args...); err != nil {
+			printError(err)
 		}
 	},
 }
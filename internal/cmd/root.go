@@ -1,18 +1,25 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"os"
+	"strings"
 	"sync"
 
 	"github.com/gowsp/cloud189/pkg"
 	"github.com/gowsp/cloud189/pkg/app"
 	"github.com/gowsp/cloud189/pkg/drive"
+	"github.com/gowsp/cloud189/pkg/file"
 	"github.com/gowsp/cloud189/pkg/invoker"
 	"github.com/spf13/cobra"
 )
 
 var (
 	cfgFile string
+	profile string
+	space   string
 	RootCmd = &cobra.Command{
 		Use:  "cloud189",
 		Long: "cloud189 enables users to manage cloud files through the command line. For more information, please visit https://github.com/gowsp/cloud189",
@@ -30,6 +37,10 @@ func Execute() {
 
 func init() {
 	RootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.config/cloud189/config.json)")
+	RootCmd.PersistentFlags().StringVar(&profile, "profile", "", "account profile in config (default is the current profile)")
+	RootCmd.PersistentFlags().StringVar(&space, "space", "personal", "cloud space, personal or family[:id]")
+	RootCmd.PersistentFlags().IntVar(&invoker.DefaultRetryPolicy.MaxAttempts, "retries", invoker.DefaultRetryPolicy.MaxAttempts, "max attempts of a request, 1 disables retry")
+	RootCmd.PersistentFlags().DurationVar(&invoker.DefaultRetryPolicy.MaxElapsed, "retry-timeout", invoker.DefaultRetryPolicy.MaxElapsed, "give up retrying a request after the duration, 0 means no limit")
 
 	RootCmd.AddCommand(loginCmd)
 	RootCmd.AddCommand(qrLoginCmd)
@@ -46,6 +57,14 @@ func init() {
 	RootCmd.AddCommand(webdavCmd)
 	RootCmd.AddCommand(webCmd)
 	RootCmd.AddCommand(shareCmd)
+	RootCmd.AddCommand(trashCmd)
+	RootCmd.AddCommand(linkCmd)
+	RootCmd.AddCommand(importCmd)
+	RootCmd.AddCommand(shareLsCmd)
+	RootCmd.AddCommand(familyCmd)
+	RootCmd.AddCommand(profileCmd)
+	RootCmd.AddCommand(taskCmd)
+	RootCmd.AddCommand(bwlimitCmd)
 }
 
 var singleton pkg.Drive
@@ -53,11 +72,101 @@ var once sync.Once
 
 func App() pkg.Drive {
 	once.Do(func() {
+		var err error
+		if singleton, err = Space(space); err != nil {
+			log.Fatalln(err)
+		}
+	})
+	return singleton
+}
+
+type familyApi interface {
+	Families() ([]pkg.Family, error)
+	Family(id string) pkg.DriveApi
+}
+
+var config *invoker.Config
+var configOnce sync.Once
+
+func loadConfig() *invoker.Config {
+	configOnce.Do(func() {
 		if cfgFile == "" {
 			cfgFile = invoker.DefaultPath()
 		}
-		api := app.New(cfgFile)
-		singleton = drive.New(api)
+		var err error
+		if config, err = invoker.OpenConfig(cfgFile); err != nil {
+			log.Fatalln(err)
+		}
 	})
-	return singleton
+	return config
+}
+
+var account familyApi
+var accountOnce sync.Once
+
+func accountApi() familyApi {
+	accountOnce.Do(func() {
+		conf, err := loadConfig().Profile(profile)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		account = app.WithConfig(conf)
+	})
+	return account
+}
+
+// Space opens drive of personal or family[:id] space
+func Space(name string) (pkg.Drive, error) {
+	return openSpace(accountApi(), name)
+}
+
+// Profile opens drive of the named profile in the selected space
+func Profile(name string) (pkg.Drive, error) {
+	conf, err := loadConfig().Profile(name)
+	if err != nil {
+		return nil, err
+	}
+	return openSpace(app.WithConfig(conf), space)
+}
+
+func openSpace(api familyApi, name string) (pkg.Drive, error) {
+	kind, id, _ := strings.Cut(name, ":")
+	switch kind {
+	case "", "personal":
+		return drive.New(api.(pkg.DriveApi)), nil
+	case "family":
+	default:
+		return nil, fmt.Errorf("unknown space %s, personal or family[:id]", name)
+	}
+	if id == "" {
+		families, err := api.Families()
+		if err != nil {
+			return nil, err
+		}
+		switch len(families) {
+		case 0:
+			return nil, errors.New("no family cloud found")
+		case 1:
+			id = families[0].Id
+		default:
+			printFamilies(families)
+			return nil, errors.New("multiple family clouds, specify one by family:id")
+		}
+	}
+	return drive.NewWithRoot(api.Family(id), file.FamilyRoot), nil
+}
+
+// printError prints err with a hint of its kind
+func printError(err error) {
+	fmt.Println(err)
+	switch {
+	case errors.Is(err, pkg.ErrSessionExpired):
+		fmt.Println("hint: login again by `cloud189 login`")
+	case errors.Is(err, pkg.ErrQuotaExceeded):
+		fmt.Println("hint: check space by `cloud189 df` and clean the trash by `cloud189 trash empty`")
+	case errors.Is(err, pkg.ErrDailyFlowLimit):
+		fmt.Println("hint: upload flow of today is used up, try again tomorrow")
+	case errors.Is(err, pkg.ErrRateLimited):
+		fmt.Println("hint: too many requests, try again later or lower parallels")
+	}
 }
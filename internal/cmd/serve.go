@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"path"
+	"time"
+
+	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/drive"
+	"github.com/spf13/cobra"
+)
+
+// serveOpts are options of webdav, share and web servers
+var serveOpts pkg.ServerOptions
+
+// serveDrives roots drives of mounts at --root
+func serveDrives(mounts map[string]pkg.Drive) (map[string]pkg.Drive, error) {
+	if path.Clean("/"+serveOpts.Root) == "/" {
+		return mounts, nil
+	}
+	drives := make(map[string]pkg.Drive, len(mounts))
+	for prefix, d := range mounts {
+		sub, err := drive.Sub(d, serveOpts.Root)
+		if err != nil {
+			return nil, err
+		}
+		drives[prefix] = sub
+	}
+	return drives, nil
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{webdavCmd, shareCmd, webCmd} {
+		flags := cmd.Flags()
+		flags.StringVar(&serveOpts.Prefix, "prefix", "", "url prefix to serve under, such as the path behind a reverse proxy")
+		flags.StringVar(&serveOpts.Root, "root", "", "cloud dir to expose, whole drive by default")
+		flags.BoolVar(&serveOpts.TLS, "tls", false, "serve https, by a self-signed certificate unless --tls-cert and --tls-key are given")
+		flags.StringVar(&serveOpts.CertFile, "tls-cert", "", "certificate file of https")
+		flags.StringVar(&serveOpts.KeyFile, "tls-key", "", "key file of https")
+		flags.DurationVar(&serveOpts.ReadTimeout, "read-timeout", 0, "timeout of reading a request including body, 0 means no limit")
+		flags.DurationVar(&serveOpts.WriteTimeout, "write-timeout", 0, "timeout of writing a response, 0 means no limit")
+		flags.DurationVar(&serveOpts.IdleTimeout, "idle-timeout", 2*time.Minute, "timeout of idle keep-alive connections")
+	}
+}
@@ -4,23 +4,85 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
 
+	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/drive"
 	"github.com/spf13/cobra"
 )
 
+var (
+	shareReadme bool
+	shareToken  string
+	sharePublic bool
+	shareProxy  bool
+	shareConns  int
+	shareTTL    time.Duration
+	shareURL    string
+)
+
 var shareCmd = &cobra.Command{
 	Use:   "share",
 	Short: "file direct link sharing",
 	Args:  cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
-		handler, err := App().Share("/", args[1])
+		prefix := strings.TrimSuffix(serveOpts.Prefix, "/")
+		cfg := pkg.ShareConfig{Prefix: prefix + "/", Readme: shareReadme, Token: shareToken,
+			Proxy: shareProxy, ProxyConns: shareConns}
+		if !sharePublic {
+			key, err := loadConfig().ShareKey()
+			if err != nil {
+				printError(err)
+				return
+			}
+			cfg.Secret = key
+		}
+		handler, err := App().Share(cfg, path.Join("/", serveOpts.Root, args[1]))
 		if err != nil {
-			fmt.Println(err)
+			printError(err)
 			return
 		}
 		mux := http.NewServeMux()
-		mux.HandleFunc("/", handler)
-		log.Println("start share serve at", args[0])
-		http.ListenAndServe(args[0], mux)
+		mux.HandleFunc(prefix+"/", handler)
+		serveOpts.Addr = args[0]
+		log.Println("start share serve at", serveOpts.URL()+"/")
+		if err = serveOpts.ListenAndServe(mux); err != nil {
+			printError(err)
+		}
+	},
+}
+
+var shareSignCmd = &cobra.Command{
+	Use:   "sign",
+	Short: "print links of paths in share valid for a period",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		key, err := loadConfig().ShareKey()
+		if err != nil {
+			printError(err)
+			return
+		}
+		base := strings.TrimSuffix(shareURL, "/")
+		exp := time.Now().Add(shareTTL)
+		for _, name := range args {
+			name = path.Clean("/" + name)
+			u := url.URL{Path: name}
+			fmt.Println(base + u.EscapedPath() + "?" + drive.SignShare(key, name, exp))
+		}
 	},
 }
+
+func init() {
+	flags := shareCmd.Flags()
+	flags.BoolVar(&shareReadme, "readme", false, "show README of dir below its listing")
+	flags.StringVar(&shareToken, "token", "", "bearer token granting access to the whole share")
+	flags.BoolVar(&sharePublic, "public", false, "serve without auth, signed links are not required")
+	flags.BoolVar(&shareProxy, "proxy", false, "stream files through the server instead of redirecting to cloud links")
+	flags.IntVar(&shareConns, "proxy-conns", 8, "max cloud connections of --proxy, 0 means no limit")
+	shareSignCmd.Flags().DurationVar(&shareTTL, "ttl", 24*time.Hour, "period the links are valid for")
+	shareSignCmd.Flags().StringVar(&shareURL, "url", "http://localhost:8080", "base url of share server, including --prefix")
+	shareCmd.AddCommand(shareSignCmd)
+}
@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"fmt"
-
 	"github.com/gowsp/cloud189/pkg/app"
 	"github.com/gowsp/cloud189/pkg/invoker"
 	"github.com/spf13/cobra"
@@ -17,7 +15,7 @@ var signCmd = &cobra.Command{
 		}
 		app := app.New(cfgFile)
 		if err := app.Sign(); err != nil {
-			fmt.Println(err)
+			printError(err)
 		}
 	},
 }
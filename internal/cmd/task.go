@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gowsp/cloud189/pkg"
+	"github.com/spf13/cobra"
+)
+
+var (
+	taskAsync    bool
+	taskTimeout  time.Duration
+	taskConflict string
+)
+
+var taskCmd = &cobra.Command{
+	Use:   "task",
+	Short: "show status of batch task, arg: task id",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		info, err := App().Task(args[0])
+		if err != nil {
+			printError(err)
+			return
+		}
+		fmt.Println(info)
+		for _, f := range info.Conflicts {
+			fmt.Printf("%-10s%s\n", f.Result, f.Name)
+		}
+	},
+}
+
+func taskConfig() (pkg.TaskConfig, error) {
+	policy, err := pkg.ParseConflictPolicy(taskConflict)
+	if err != nil {
+		return pkg.TaskConfig{}, err
+	}
+	return pkg.TaskConfig{Async: taskAsync, Timeout: taskTimeout, Conflict: policy, Progress: printTask}, nil
+}
+
+func printTask(info pkg.TaskInfo) {
+	switch info.Status {
+	case pkg.TaskInit:
+		if taskAsync {
+			fmt.Println("task submitted:", info.Id)
+		}
+	case pkg.TaskRunning:
+		fmt.Printf("\r%s", &info)
+	default:
+		fmt.Printf("\r%s\n", &info)
+		for _, f := range info.Files {
+			fmt.Printf("%-10s%s\n", f.Result, f.Name)
+		}
+	}
+}
+
+func addTaskFlags(cmds ...*cobra.Command) {
+	for _, cmd := range cmds {
+		cmd.Flags().BoolVar(&taskAsync, "async", false, "return task id once submitted, check it with task command")
+		cmd.Flags().DurationVar(&taskTimeout, "timeout", 10*time.Minute, "max time to wait for the task")
+	}
+}
+
+func init() {
+	addTaskFlags(cpCmd, mvCmd, rmCmd)
+	for _, cmd := range []*cobra.Command{cpCmd, mvCmd} {
+		cmd.Flags().StringVar(&taskConflict, "on-conflict", "fail", "policy of same-named item in target, skip, overwrite, rename or fail")
+	}
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/gowsp/cloud189/pkg/file"
+	"github.com/peterh/liner"
+	"github.com/spf13/cobra"
+)
+
+var emptyConfirm bool
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "manage recycle bin",
+}
+
+var trashLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "list recycle bin",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		files, err := App().Trash()
+		if err != nil {
+			printError(err)
+			return
+		}
+		for _, v := range files {
+			fmt.Printf("%-22s%s\n", v.Id(), file.ReadableFileInfo(v))
+		}
+	},
+}
+
+var trashRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "restore file from recycle bin to its original path, arg: name or id",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := App().Restore(args...); err != nil {
+			printError(err)
+		}
+	},
+}
+
+var trashRmCmd = &cobra.Command{
+	Use:   "rm",
+	Short: "delete file from recycle bin permanently, arg: name or id",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := App().Purge(args...); err != nil {
+			printError(err)
+		}
+	},
+}
+
+var trashEmptyCmd = &cobra.Command{
+	Use:          "empty",
+	Short:        "empty recycle bin",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !emptyConfirm {
+			liner := liner.NewLiner()
+			defer liner.Close()
+			reply, err := liner.Prompt("Are you sure to empty the recycle bin? (y/n) ")
+			if err != nil {
+				return err
+			}
+			if reply != "y" && reply != "Y" {
+				return nil
+			}
+		}
+		return App().EmptyTrash()
+	},
+}
+
+func init() {
+	trashEmptyCmd.Flags().BoolVarP(&emptyConfirm, "f", "f", false, "empty without confirmation")
+	trashCmd.AddCommand(trashLsCmd, trashRestoreCmd, trashRmCmd, trashEmptyCmd)
+}
@@ -1,19 +1,22 @@
 package cmd
 
 import (
-	"fmt"
-
 	"github.com/gowsp/cloud189/internal/session"
 	"github.com/gowsp/cloud189/pkg"
 	"github.com/gowsp/cloud189/pkg/file"
 	"github.com/spf13/cobra"
 )
 
-var upCfg pkg.UploadConfig
+var (
+	upCfg      pkg.UploadConfig
+	upConflict string
+)
 
 func init() {
 	upCmd.Flags().Uint32VarP(&upCfg.Num, "parallel", "p", 5, "number of parallels for file upload")
 	upCmd.Flags().StringVarP(&upCfg.Parten, "name", "n", "", "filter filename regular expression")
+	upCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "do not print progress")
+	upCmd.Flags().StringVar(&upConflict, "on-conflict", "", "policy of same-named file in cloud, skip, overwrite, rename, newer or fail, default keeps server behavior")
 }
 
 var upCmd = &cobra.Command{
@@ -25,12 +28,17 @@ var upCmd = &cobra.Command{
 		cloud := session.Join(args[length-1])
 		err := file.CheckPath(cloud)
 		if err != nil {
-			fmt.Println(err)
+			printError(err)
+			return
+		}
+		if upCfg.Conflict, err = pkg.ParseUploadPolicy(upConflict); err != nil {
+			printError(err)
 			return
 		}
+		upCfg.Progress = newProgress()
 		locals := args[:length-1]
 		if err := App().Upload(upCfg, cloud, locals...); err != nil {
-			fmt.Println(err)
+			printError(err)
 		}
 	},
 }
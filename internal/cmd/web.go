@@ -1,10 +1,16 @@
 package cmd
 
 import (
+	"github.com/gowsp/cloud189/pkg"
 	"github.com/gowsp/cloud189/pkg/webui"
 	"github.com/spf13/cobra"
 )
 
+var (
+	webMounts   []string
+	webConflict string
+)
+
 var webCmd = &cobra.Command{
 	Use:   "web",
 	Short: "start web server with modern UI, arg: port (default: 8080)",
@@ -15,6 +21,30 @@ var webCmd = &cobra.Command{
 		if len(args) > 0 {
 			port = args[0]
 		}
-		webui.Serve(port, App())
+		policy, err := pkg.ParseUploadPolicy(webConflict)
+		if err != nil {
+			printError(err)
+			return
+		}
+		web := &webui.Options{Conflict: policy}
+		if web.Locks, err = openLocks(); err != nil {
+			printError(err)
+			return
+		}
+		drives, accounts, err := mountDrives(webMounts)
+		if err != nil {
+			printError(err)
+			return
+		}
+		web.Accounts = accounts
+		serveOpts.Addr = ":" + port
+		if err = webui.ServeMounts(&serveOpts, web, drives); err != nil {
+			printError(err)
+		}
 	},
-}
\ No newline at end of file
+}
+
+func init() {
+	webCmd.Flags().StringArrayVar(&webMounts, "mount", nil, "mount profile at url prefix, format: /prefix=profile")
+	webCmd.Flags().StringVar(&webConflict, "on-conflict", "overwrite", "policy of uploading same-named file, skip, overwrite, rename, newer or fail")
+}
@@ -1,15 +1,111 @@
 package cmd
 
 import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/file"
+	"github.com/gowsp/cloud189/pkg/util"
 	"github.com/gowsp/cloud189/pkg/webdav"
 	"github.com/spf13/cobra"
 )
 
+var (
+	davMounts   []string
+	davConflict string
+	davReadOnly bool
+	spoolDir    string
+	spoolLimit  string
+)
+
 var webdavCmd = &cobra.Command{
 	Use:   "webdav",
 	Short: "start webdav server, arg: port",
 	Args:  cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		webdav.Serve(args[0], App())
+		policy, err := pkg.ParseUploadPolicy(davConflict)
+		if err != nil {
+			printError(err)
+			return
+		}
+		dav := &webdav.Options{Conflict: policy, Users: loadConfig().Webdav, ReadOnly: davReadOnly}
+		if dav.Locks, err = openLocks(); err != nil {
+			printError(err)
+			return
+		}
+		limit, err := util.ParseRate(spoolLimit)
+		if err != nil {
+			printError(fmt.Errorf("invalid spool limit %s, example: 20G", spoolLimit))
+			return
+		}
+		dav.Spool = &file.Spool{Dir: spoolDir, Limit: int64(limit)}
+		if err = dav.Spool.Clean(); err != nil {
+			printError(err)
+		}
+		drives, accounts, err := mountDrives(davMounts)
+		if err != nil {
+			printError(err)
+			return
+		}
+		dav.Accounts = accounts
+		serveOpts.Addr = args[0]
+		if err = webdav.ServeMounts(&serveOpts, dav, drives); err != nil {
+			printError(err)
+		}
 	},
 }
+
+// openLocks opens webdav locks saved beside config, which are shared by webdav and web servers
+func openLocks() (*webdav.FileLS, error) {
+	loadConfig()
+	return webdav.OpenFileLS(filepath.Join(filepath.Dir(cfgFile), "locks.json"))
+}
+
+// mountDrives parses prefix=profile pairs into drives of the profiles rooted at --root and their accounts,
+// the current drive is served at / without mounts
+func mountDrives(mounts []string) (map[string]pkg.Drive, map[string]string, error) {
+	if len(mounts) == 0 {
+		drives, err := serveDrives(map[string]pkg.Drive{"": App()})
+		return drives, map[string]string{"": accountName(profile)}, err
+	}
+	drives := make(map[string]pkg.Drive, len(mounts))
+	accounts := make(map[string]string, len(mounts))
+	for _, mount := range mounts {
+		prefix, name, ok := strings.Cut(mount, "=")
+		if !ok || !strings.HasPrefix(prefix, "/") {
+			return nil, nil, fmt.Errorf("invalid mount %s, example: /work=work", mount)
+		}
+		d, err := Profile(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		prefix = strings.TrimSuffix(prefix, "/")
+		drives[prefix], accounts[prefix] = d, accountName(name)
+	}
+	drives, err := serveDrives(drives)
+	return drives, accounts, err
+}
+
+// accountName names the profile and space for locks, the current profile when name is empty
+func accountName(name string) string {
+	if name == "" {
+		name = loadConfig().Current
+	}
+	if name == "" {
+		name = "default"
+	}
+	if space != "" && space != "personal" {
+		name += "/" + space
+	}
+	return name
+}
+
+func init() {
+	webdavCmd.Flags().StringArrayVar(&davMounts, "mount", nil, "mount profile at url prefix, format: /prefix=profile")
+	webdavCmd.Flags().StringVar(&davConflict, "on-conflict", "overwrite", "policy of uploading same-named file, skip, overwrite, rename, newer or fail")
+	webdavCmd.Flags().StringVar(&spoolDir, "spool-dir", "", "dir keeping uploads before sending to cloud, system temp dir by default")
+	webdavCmd.Flags().StringVar(&spoolLimit, "spool-limit", "off", "max size of files kept in spool dir such as 20G, off for unlimited")
+	webdavCmd.Flags().BoolVar(&davReadOnly, "read-only", false, "serve files without changes, such as upload, delete or move")
+}
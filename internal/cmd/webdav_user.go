@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gowsp/cloud189/pkg/auth"
+	"github.com/peterh/liner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	davUserRoot     string
+	davUserReadOnly bool
+	davUserDigest   bool
+)
+
+var webdavUserCmd = &cobra.Command{
+	Use:   "user",
+	Short: "manage webdav users, the server requires auth once a user is added",
+}
+
+var webdavUserAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "add webdav user or reset its password, prompt for password",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		line := liner.NewLiner()
+		password, err := line.PasswordPrompt("password: ")
+		if err == nil {
+			var confirm string
+			if confirm, err = line.PasswordPrompt("confirm password: "); err == nil && confirm != password {
+				err = errors.New("passwords do not match")
+			}
+		}
+		line.Close()
+		if err != nil {
+			printError(err)
+			return
+		}
+		user, err := auth.NewUser(args[0], password, davUserDigest)
+		if err != nil {
+			printError(err)
+			return
+		}
+		user.Root, user.ReadOnly = davUserRoot, davUserReadOnly
+		if err = loadConfig().SetWebdavUser(user); err != nil {
+			printError(err)
+		}
+	},
+}
+
+var webdavUserLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "list webdav users",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, user := range loadConfig().Webdav {
+			root, mode := user.Root, "rw"
+			if root == "" {
+				root = "/"
+			}
+			if user.ReadOnly {
+				mode = "ro"
+			}
+			fmt.Printf("%-20s%-4s%s\n", user.Name, mode, root)
+		}
+	},
+}
+
+var webdavUserRmCmd = &cobra.Command{
+	Use:   "rm",
+	Short: "remove webdav user",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, name := range args {
+			if err := loadConfig().RemoveWebdavUser(name); err != nil {
+				printError(err)
+			}
+		}
+	},
+}
+
+func init() {
+	webdavUserAddCmd.Flags().StringVar(&davUserRoot, "root", "", "cloud dir served as root of the user, whole drive by default")
+	webdavUserAddCmd.Flags().BoolVar(&davUserReadOnly, "read-only", false, "forbid the user to change files")
+	webdavUserAddCmd.Flags().BoolVar(&davUserDigest, "digest", false, "enable digest auth, keeps md5 of the password which is as sensitive as the password")
+	webdavUserCmd.AddCommand(webdavUserAddCmd, webdavUserLsCmd, webdavUserRmCmd)
+	webdavCmd.AddCommand(webdavUserCmd)
+}
@@ -36,6 +36,14 @@ func completer(line string) (c []string) {
 		return
 	}
 	arg := args[len-1]
+	if strings.ContainsAny(arg, "*?[") {
+		matches, err := cmd.App().Glob(session.Join(arg))
+		if err != nil || matches == nil {
+			return
+		}
+		args[len-1] = strings.Join(matches, " ")
+		return []string{strings.Join(args, " ")}
+	}
 	dir, n := path.Split(arg)
 	name := session.Join(dir)
 	files, err := cmd.App().ReadDir(name)
@@ -11,6 +11,51 @@ type Space struct {
 	Capacity  uint64 `json:"capacity,omitempty"`
 }
 
+type ShareLink struct {
+	Id         string    `json:"id"`
+	FileId     string    `json:"fileId,omitempty"`
+	Name       string    `json:"name"`
+	IsDir      bool      `json:"isDir"`
+	Url        string    `json:"url"`
+	AccessCode string    `json:"accessCode,omitempty"`
+	CreateTime time.Time `json:"createTime"`
+	// zero means never expires
+	ExpireTime time.Time `json:"expireTime"`
+}
+
+type Family struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// ShareInfo describes a share link resolved by its code
+type ShareInfo struct {
+	Id         string
+	Code       string
+	AccessCode string
+	Mode       string
+	FileId     string
+	Name       string
+	Size       int64
+	IsDir      bool
+}
+
+// ShareConfig configures the server sharing cloud files by direct links
+type ShareConfig struct {
+	// url prefix of shared files
+	Prefix string
+	// show README.md, README.txt or README of a dir below its listing
+	Readme bool
+	// requests need header Authorization: Bearer {Token}, or a link signed by Secret,
+	// the share is public when both are empty
+	Token  string
+	Secret []byte
+	// stream files through the server instead of redirecting to the cloud url
+	Proxy bool
+	// max upstream connections of proxy, 0 is unlimited
+	ProxyConns int
+}
+
 type File interface {
 	Id() string
 	PId() string
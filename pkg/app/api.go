@@ -1,13 +1,14 @@
 package app
 
 import (
-	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
+	"sync"
 	"time"
 
+	"github.com/gowsp/cloud189/pkg"
 	"github.com/gowsp/cloud189/pkg/invoker"
 	"github.com/gowsp/cloud189/pkg/util"
 )
@@ -15,10 +16,19 @@ import (
 type api struct {
 	invoker *invoker.Invoker
 	conf    *invoker.Config
+	// family cloud id, empty for personal space
+	family string
+	// task id -> taskType of submitted tasks, needed to resolve conflicts
+	tasks sync.Map
 }
 
 func New(path string) *api {
 	conf, _ := invoker.OpenConfig(path)
+	return WithConfig(conf)
+}
+
+// WithConfig creates api of the account in conf, such as a profile
+func WithConfig(conf *invoker.Config) *api {
 	api := &api{conf: conf}
 	api.invoker = invoker.NewInvoker("https://api.cloud.189.cn", api.refresh, conf)
 	api.invoker.SetPrepare(api.sign)
@@ -46,11 +56,14 @@ func (api *api) refresh() error {
 		s.Merge(newSession)
 		return api.conf.Save()
 	}
-	user := api.conf.User
-	if user.Name == "" || user.Password == "" {
-		return errors.New("用户未登录或扫码不支持自动重新登录")
+	password, err := api.conf.Password()
+	if err != nil {
+		return err
 	}
-	return api.PwdLogin(api.conf.User.Name, api.conf.User.Password)
+	if password == "" {
+		return fmt.Errorf("%w: 用户未登录或扫码不支持自动重新登录", pkg.ErrSessionExpired)
+	}
+	return api.PwdLogin(api.conf.User.Name, password)
 }
 
 func (api *api) sign(req *http.Request) {
@@ -68,17 +81,21 @@ func (api *api) sign(req *http.Request) {
 	if session.Empty() {
 		return
 	}
+	key, secret := session.Key, session.Secret
+	if api.family != "" {
+		key, secret = session.FamilyKey, session.FamilySecret
+	}
 	date := now.Format(time.RFC1123)
 	data := fmt.Sprintf("SessionKey=%s&Operate=%s&RequestURI=%s&Date=%s",
-		session.Key, req.Method, req.URL.Path, date)
+		key, req.Method, req.URL.Path, date)
 	// 追加上传参数
 	if req.Host == "upload.cloud.189.cn" {
 		data += "&params=" + query.Get("params")
 	}
 	req.Header.Set("Date", date)
 	req.Header.Set("user-agent", "desktop")
-	req.Header.Set("SessionKey", session.Key)
-	req.Header.Set("Signature", util.Sha1(data, session.Secret))
+	req.Header.Set("SessionKey", key)
+	req.Header.Set("Signature", util.Sha1(data, secret))
 	req.Header.Set("X-Request-ID", util.Random("xxxxxxxx-xxxx-4xxx-yxxx-xxxxxxxxxxxx"))
 }
 
@@ -89,9 +106,9 @@ func (api *api) Logout() error {
 		api.conf.Session = &invoker.Session{}
 	}
 
-	// 清除用户信息
-	if api.conf.User != nil {
-		api.conf.User = &invoker.User{}
+	// 清除用户信息, 包括凭据助手中保存的密码
+	if err := api.conf.ClearUser(); err != nil {
+		return err
 	}
 
 	// 清除其他认证信息
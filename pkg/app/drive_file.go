@@ -3,6 +3,7 @@ package app
 import (
 	"encoding/json"
 	"io/fs"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -11,6 +12,10 @@ type Time time.Time
 
 func (j *Time) UnmarshalJSON(b []byte) error {
 	json := string(b)
+	if ms, err := strconv.ParseInt(json, 10, 64); err == nil {
+		*j = Time(time.UnixMilli(ms))
+		return nil
+	}
 	s := strings.Trim(json, "\"")
 	t, err := time.Parse("2006-01-02 15:04:05", s)
 	if err != nil {
@@ -72,3 +77,4 @@ func (f *fileInfo) Type() fs.FileMode  { return fs.ModeType }
 func (f *fileInfo) ModTime() time.Time { return time.Time(f.LastOpTime) }
 func (f *fileInfo) IsDir() bool        { return false }
 func (f *fileInfo) Sys() any           { return nil }
+func (f *fileInfo) FileMD5() string    { return f.Md5 }
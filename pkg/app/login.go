@@ -24,7 +24,7 @@ func (api *api) PwdLogin(username, password string) (err error) {
 	if err != nil {
 		return err
 	}
-	api.conf.User = user
+	api.conf.SetUser(username, password)
 	return api.afterLogin(resp)
 }
 
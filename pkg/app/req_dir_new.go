@@ -2,7 +2,6 @@ package app
 
 import (
 	"encoding/json"
-	"errors"
 	"net/url"
 	"path"
 
@@ -10,30 +9,20 @@ import (
 )
 
 type makeDirResp struct {
-	ResCode    int    `json:"res_code,omitempty"`
-	ResMessage string `json:"res_message,omitempty"`
+	pkg.Result
 
 	Folder *folder
 }
 
 func (r *makeDirResp) Error() error {
-	if r.ResCode == 0 {
-		return nil
-	}
-	return errors.New(r.ResMessage)
+	return r.Err("/createFolder.action")
 }
 
 func (r *makeDirResp) UnmarshalJSON(b []byte) error {
-	resp := &struct {
-		ResCode    int    `json:"res_code,omitempty"`
-		ResMessage string `json:"res_message,omitempty"`
-	}{}
-	if err := json.Unmarshal(b, &resp); err != nil {
+	if err := json.Unmarshal(b, &r.Result); err != nil {
 		return err
 	}
-	r.ResCode = resp.ResCode
-	r.ResMessage = resp.ResMessage
-	if resp.ResCode != 0 {
+	if r.Error() != nil {
 		return nil
 	}
 	var folderData folder
@@ -48,7 +37,10 @@ func (c *api) Mkdir(parent pkg.File, name string) (pkg.File, error) {
 	var result makeDirResp
 	dir, base := path.Split(name)
 	params := url.Values{"folderName": {base}, "relativePath": {dir}, "parentFolderId": {parent.Id()}}
-	err := c.invoker.Post("/createFolder.action", params, &result)
+	if c.family != "" {
+		params.Set("parentId", parent.Id())
+	}
+	err := c.invoker.Post(c.route("/createFolder.action", params), params, &result)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,29 @@
+package app
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+
+	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/file"
+)
+
+func TestMkdirExists(t *testing.T) {
+	dir := &file.FileInfo{FileId: "10", IsFolder: true}
+	for _, body := range []string{
+		`{"res_code":"FileAlreadyExists","res_message":"文件已存在"}`,
+		`{"res_code":1,"res_message":"文件已存在","errorCode":"FolderAlreadyExists"}`,
+	} {
+		c, _ := fakeApi(t, "", map[string]string{"/createFolder.action": body})
+		_, err := c.Mkdir(dir, "a")
+		if !errors.Is(err, pkg.ErrFileExists) || !errors.Is(err, fs.ErrExist) {
+			t.Errorf("%s: %v", body, err)
+		}
+	}
+	c, _ := fakeApi(t, "", map[string]string{"/createFolder.action": `{"res_code":0,"id":"11","name":"a"}`})
+	f, err := c.Mkdir(dir, "a")
+	if err != nil || f.Id() != "11" {
+		t.Errorf("created: %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+
+	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/invoker"
+)
+
+var errFamily = errors.New("not supported in family cloud")
+
+type familyResp struct {
+	Families []struct {
+		FamilyId   json.Number `json:"familyId"`
+		RemarkName string      `json:"remarkName"`
+	} `json:"familyInfoResp"`
+}
+
+func (c *api) Families() ([]pkg.Family, error) {
+	var resp familyResp
+	if err := c.invoker.Get("/family/manage/getFamilyList.action", nil, &resp); err != nil {
+		return nil, err
+	}
+	result := make([]pkg.Family, len(resp.Families))
+	for i, f := range resp.Families {
+		result[i] = pkg.Family{Id: f.FamilyId.String(), Name: f.RemarkName}
+	}
+	return result, nil
+}
+
+// Family returns api bound to the family cloud, requests are signed by the family session
+func (c *api) Family(id string) pkg.DriveApi {
+	family := &api{conf: c.conf, family: id}
+	family.invoker = invoker.NewInvoker("https://api.cloud.189.cn", c.refresh, c.conf)
+	family.invoker.SetPrepare(family.sign)
+	return family
+}
+
+func (c *api) FamilyId() string {
+	return c.family
+}
+
+// route returns the family variant of path when bound to a family cloud
+func (c *api) route(path string, params url.Values) string {
+	if c.family == "" {
+		return path
+	}
+	params.Set("familyId", c.family)
+	return "/family/file" + path
+}
+
+func (c *api) SaveToPerson(target pkg.File, files ...pkg.File) (string, error) {
+	return c.spaceCopy("2", target, files...)
+}
+
+func (c *api) SaveFromPerson(target pkg.File, files ...pkg.File) (string, error) {
+	return c.spaceCopy("1", target, files...)
+}
+
+func (c *api) spaceCopy(copyType string, target pkg.File, files ...pkg.File) (string, error) {
+	if c.family == "" {
+		return "", errors.New("copy between spaces requires family cloud")
+	}
+	if len(files) == 0 {
+		return "", nil
+	}
+	return c.submitTask(copyTask, target.Id(), url.Values{"copyType": {copyType}}, files...)
+}
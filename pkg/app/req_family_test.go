@@ -0,0 +1,101 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gowsp/cloud189/pkg/file"
+	"github.com/gowsp/cloud189/pkg/invoker"
+)
+
+// request received by fakeApi
+type fakeRequest struct {
+	path   string
+	params url.Values
+}
+
+// fakeApi returns api of family, or personal space when empty, against a server answering body
+// of path in responses, "{}" for others
+func fakeApi(t *testing.T, family string, responses map[string]string) (*api, *[]fakeRequest) {
+	var requests []fakeRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		requests = append(requests, fakeRequest{path: r.URL.Path, params: r.Form})
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			body = "{}"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	conf := &invoker.Config{}
+	c := &api{conf: conf, family: family}
+	c.invoker = invoker.NewInvoker(server.URL, func() error { return nil }, conf)
+	c.invoker.SetPrepare(c.sign)
+	c.invoker.SetRetry(invoker.RetryPolicy{MaxAttempts: 1})
+	return c, &requests
+}
+
+func TestFamilyRoute(t *testing.T) {
+	dir := &file.FileInfo{FileId: "10", IsFolder: true}
+	cases := []struct {
+		name   string
+		family string
+		call   func(c *api) error
+		path   string
+		params map[string]string
+	}{
+		{"personal detail", "", func(c *api) error { _, err := c.Detail("1"); return err },
+			"/getFileDownloadUrl.action", map[string]string{"fileId": "1", "familyId": ""}},
+		{"family detail", "f1", func(c *api) error { _, err := c.Detail("1"); return err },
+			"/family/file/getFileDownloadUrl.action", map[string]string{"fileId": "1", "familyId": "f1"}},
+		{"personal mkdir", "", func(c *api) error { _, err := c.Mkdir(dir, "a"); return err },
+			"/createFolder.action", map[string]string{"parentFolderId": "10", "parentId": "", "folderName": "a"}},
+		{"family mkdir", "f1", func(c *api) error { _, err := c.Mkdir(dir, "a/b"); return err },
+			"/family/file/createFolder.action", map[string]string{"parentId": "10", "familyId": "f1", "folderName": "b", "relativePath": "a/"}},
+		{"family rename", "f1", func(c *api) error { return c.Rename(&file.FileInfo{FileId: "2"}, "b") },
+			"/family/file/renameFile.action", map[string]string{"fileId": "2", "familyId": "f1"}},
+		{"save to person", "f1", func(c *api) error { _, err := c.SaveToPerson(dir, &file.FileInfo{FileId: "2"}); return err },
+			"/batch/createBatchTask.action", map[string]string{"type": "COPY", "copyType": "2", "familyId": "f1", "targetFolderId": "10"}},
+		{"save from person", "f1", func(c *api) error { _, err := c.SaveFromPerson(dir, &file.FileInfo{FileId: "2"}); return err },
+			"/batch/createBatchTask.action", map[string]string{"type": "COPY", "copyType": "1", "familyId": "f1", "targetFolderId": "10"}},
+		{"personal copy", "", func(c *api) error { _, err := c.Copy(dir, &file.FileInfo{FileId: "2"}); return err },
+			"/batch/createBatchTask.action", map[string]string{"type": "COPY", "copyType": "", "familyId": ""}},
+	}
+	for _, tc := range cases {
+		c, requests := fakeApi(t, tc.family, map[string]string{
+			"/batch/createBatchTask.action": `{"res_code":0,"taskId":"t1"}`,
+		})
+		if err := tc.call(c); err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if len(*requests) != 1 || (*requests)[0].path != tc.path {
+			t.Errorf("%s: requests %v, want %s", tc.name, *requests, tc.path)
+			continue
+		}
+		params := (*requests)[0].params
+		for k, v := range tc.params {
+			if got := params.Get(k); got != v {
+				t.Errorf("%s: param %s=%q, want %q", tc.name, k, got, v)
+			}
+		}
+	}
+}
+
+func TestSaveToPersonRequiresFamily(t *testing.T) {
+	c, requests := fakeApi(t, "", nil)
+	dir := &file.FileInfo{FileId: "10", IsFolder: true}
+	if _, err := c.SaveToPerson(dir, &file.FileInfo{FileId: "2"}); err == nil {
+		t.Error("copy between spaces of personal api succeeded")
+	}
+	if _, err := c.SaveFromPerson(dir, &file.FileInfo{FileId: "2"}); err == nil {
+		t.Error("copy between spaces of personal api succeeded")
+	}
+	if len(*requests) != 0 {
+		t.Errorf("requests sent %v", *requests)
+	}
+}
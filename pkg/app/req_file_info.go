@@ -10,20 +10,32 @@ import (
 )
 
 func (c *api) Detail(id string) (string, error) {
-	var info map[string]string
-	err := c.invoker.Get("/getFileDownloadUrl.action", url.Values{"fileId": {id}}, &info)
-	return info["fileDownloadUrl"], err
+	var info struct {
+		pkg.Result
+		FileDownloadUrl string `json:"fileDownloadUrl"`
+	}
+	params := url.Values{"fileId": {id}}
+	op := c.route("/getFileDownloadUrl.action", params)
+	if err := c.invoker.Get(op, params, &info); err != nil {
+		return "", err
+	}
+	return info.FileDownloadUrl, info.Err(op)
 }
 
 func (c *api) Download(file pkg.File, start int64) (*http.Response, error) {
 	if file.IsDir() {
 		return nil, errors.New("not support download dir")
 	}
-	url, _ := c.Detail(file.Id())
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	url, err := c.Detail(file.Id())
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, file.Size()))
-	return c.invoker.Send(req)
+	return c.invoker.Open(func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, file.Size()))
+		return req, nil
+	})
 }
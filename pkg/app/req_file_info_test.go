@@ -0,0 +1,22 @@
+package app
+
+import "testing"
+
+func TestDetail(t *testing.T) {
+	cases := []struct {
+		body, url string
+		fail      bool
+	}{
+		{`{"res_code":0,"res_message":"成功","fileDownloadUrl":"https://download/a"}`, "https://download/a", false},
+		{`{"res_code":"0","fileDownloadUrl":"https://download/b"}`, "https://download/b", false},
+		{`{"fileDownloadUrl":"https://download/c"}`, "https://download/c", false},
+		{`{"res_code":1,"res_message":"文件不存在","errorCode":"FileNotFound"}`, "", true},
+	}
+	for _, tc := range cases {
+		c, _ := fakeApi(t, "", map[string]string{"/getFileDownloadUrl.action": tc.body})
+		url, err := c.Detail("1")
+		if url != tc.url || (err != nil) != tc.fail {
+			t.Errorf("%s: %q %v", tc.body, url, err)
+		}
+	}
+}
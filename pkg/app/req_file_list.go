@@ -52,7 +52,7 @@ func (c *api) list(id, fileType string, page int) (result []pkg.File, err error)
 	params.Set("pageSize", "100")
 
 	var resp listFileResp
-	err = c.invoker.Get("/listFiles.action", params, &resp)
+	err = c.invoker.Get(c.route("/listFiles.action", params), params, &resp)
 	if err != nil {
 		return
 	}
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"encoding/json"
+	"io/fs"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gowsp/cloud189/pkg"
+)
+
+type recycleFile struct {
+	ID         json.Number `json:"id"`
+	ParentID   json.Number `json:"parentId"`
+	FileName   string      `json:"name"`
+	FileSize   int64       `json:"size"`
+	Md5        string      `json:"md5"`
+	LastOpTime Time        `json:"lastOpTime"`
+	folder     bool
+}
+
+func (f *recycleFile) Info() (fs.FileInfo, error) { return f, nil }
+
+func (f *recycleFile) Id() string         { return f.ID.String() }
+func (f *recycleFile) PId() string        { return f.ParentID.String() }
+func (f *recycleFile) Name() string       { return f.FileName }
+func (f *recycleFile) Size() int64        { return f.FileSize }
+func (f *recycleFile) ModTime() time.Time { return time.Time(f.LastOpTime) }
+func (f *recycleFile) IsDir() bool        { return f.folder }
+func (f *recycleFile) Sys() any           { return nil }
+func (f *recycleFile) Type() fs.FileMode  { return f.Mode().Type() }
+func (f *recycleFile) Mode() fs.FileMode {
+	if f.folder {
+		return fs.ModeDir
+	}
+	return fs.ModeType
+}
+
+type recycleResult struct {
+	Count   int            `json:"count"`
+	Files   []*recycleFile `json:"fileList"`
+	Folders []*recycleFile `json:"folderList"`
+}
+
+func (c *api) ListRecycle() ([]pkg.File, error) {
+	if c.family != "" {
+		return nil, errFamily
+	}
+	return c.listRecycle(1)
+}
+
+func (c *api) listRecycle(page int) (result []pkg.File, err error) {
+	params := make(url.Values)
+	params.Set("pageNum", strconv.Itoa(page))
+	params.Set("pageSize", "100")
+	params.Set("iconOption", "0")
+	var resp recycleResult
+	if err = c.invoker.Get("/listRecycleBinFiles.action", params, &resp); err != nil {
+		return
+	}
+	for _, f := range resp.Folders {
+		f.folder = true
+		result = append(result, f)
+	}
+	for _, f := range resp.Files {
+		result = append(result, f)
+	}
+	if 100*page < resp.Count {
+		var more []pkg.File
+		more, err = c.listRecycle(page + 1)
+		result = append(result, more...)
+	}
+	return
+}
+
+func (c *api) Restore(files ...pkg.File) error {
+	if len(files) == 0 {
+		return nil
+	}
+	_, err := c.createTask(restoreTask, "", files...)
+	return err
+}
+
+func (c *api) DeleteRecycle(files ...pkg.File) error {
+	if len(files) == 0 {
+		return nil
+	}
+	_, err := c.createTask(clearTask, "", files...)
+	return err
+}
+
+func (c *api) EmptyRecycle() error {
+	if c.family != "" {
+		return errFamily
+	}
+	_, err := c.createTask(emptyTask, "")
+	return err
+}
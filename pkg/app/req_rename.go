@@ -26,12 +26,12 @@ func (c *api) renameFile(id, dest string) error {
 	params.Set("fileId", id)
 	params.Set("destFileName", dest)
 	var f map[string]interface{}
-	return c.invoker.Post("/renameFile.action", params, &f)
+	return c.invoker.Post(c.route("/renameFile.action", params), params, &f)
 }
 func (c *api) renameFoler(id, dest string) error {
 	params := make(url.Values)
 	params.Set("folderId", id)
 	params.Set("destFolderName", dest)
 	var result map[string]interface{}
-	return c.invoker.Post("/renameFolder.action", params, &result)
+	return c.invoker.Post(c.route("/renameFolder.action", params), params, &result)
 }
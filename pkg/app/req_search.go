@@ -35,6 +35,9 @@ func (l *searchResult) fill(id string) (data []pkg.File) {
 }
 
 func (c *api) search(id, fileType, name string, page int) (result []pkg.File, err error) {
+	if c.family != "" {
+		return c.familySearch(id, fileType, name)
+	}
 	if file.IsSystem(id, name) {
 		return c.List(file.Root, pkg.DIR)
 	}
@@ -63,3 +66,17 @@ func (c *api) search(id, fileType, name string, page int) (result []pkg.File, er
 	}
 	return
 }
+
+// familySearch filters the dir list, family cloud has no search by name in folder
+func (c *api) familySearch(id, fileType, name string) (result []pkg.File, err error) {
+	files, err := c.list(id, fileType, 1)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if f.Name() == name {
+			result = append(result, f)
+		}
+	}
+	return
+}
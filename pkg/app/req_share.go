@@ -0,0 +1,91 @@
+package app
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/file"
+)
+
+func expireParam(days int) string {
+	if days <= 0 {
+		return "2099"
+	}
+	return strconv.Itoa(days)
+}
+
+func (c *api) CreateShare(f pkg.File, expire int, withCode bool) (*pkg.ShareLink, error) {
+	if c.family != "" {
+		return nil, errFamily
+	}
+	params := make(url.Values)
+	params.Set("fileId", f.Id())
+	params.Set("expireTime", expireParam(expire))
+	if withCode {
+		params.Set("shareType", "3")
+		params.Set("withAccessCode", "1")
+	} else {
+		params.Set("shareType", "1")
+		params.Set("withAccessCode", "0")
+	}
+	var result file.ShareResp
+	if err := c.invoker.Get("/createShareLink.action", params, &result); err != nil {
+		return nil, err
+	}
+	if err := result.Err("/createShareLink.action"); err != nil {
+		return nil, err
+	}
+	return result.Link(f, expire)
+}
+
+type listShareResp struct {
+	pkg.Result
+	Count int               `json:"recordCount"`
+	Data  []*file.ShareItem `json:"data"`
+}
+
+func (c *api) ListShare() ([]pkg.ShareLink, error) {
+	if c.family != "" {
+		return nil, errFamily
+	}
+	return c.listShare(1)
+}
+
+func (c *api) listShare(page int) (result []pkg.ShareLink, err error) {
+	params := make(url.Values)
+	params.Set("pageNum", strconv.Itoa(page))
+	params.Set("pageSize", "100")
+	params.Set("shareType", "1")
+	var resp listShareResp
+	if err = c.invoker.Get("/listShares.action", params, &resp); err != nil {
+		return
+	}
+	if err := resp.Err("/listShares.action"); err != nil {
+		return nil, err
+	}
+	for _, item := range resp.Data {
+		result = append(result, item.Link())
+	}
+	if 100*page < resp.Count {
+		var more []pkg.ShareLink
+		more, err = c.listShare(page + 1)
+		result = append(result, more...)
+	}
+	return
+}
+
+func (c *api) CancelShare(id ...string) error {
+	if c.family != "" {
+		return errFamily
+	}
+	if len(id) == 0 {
+		return nil
+	}
+	params := make(url.Values)
+	params.Set("shareIdList", strings.Join(id, ","))
+	params.Set("cancelType", "1")
+	var result map[string]interface{}
+	return c.invoker.Post("/cancelShare.action", params, &result)
+}
@@ -0,0 +1,15 @@
+package app
+
+import (
+	"net/url"
+
+	"github.com/gowsp/cloud189/pkg"
+)
+
+func (c *api) SaveShare(share *pkg.ShareInfo, target pkg.File, files ...pkg.File) error {
+	if len(files) == 0 {
+		return nil
+	}
+	_, err := c.submitTask(saveTask, target.Id(), url.Values{"shareId": {share.Id}}, files...)
+	return err
+}
@@ -11,6 +11,10 @@ import (
 )
 
 func (c *api) Space() (space pkg.Space, err error) {
+	if c.family != "" {
+		err = c.invoker.Get("/family/manage/getFamilyCapacityInfo.action", url.Values{"familyId": {c.family}}, &space)
+		return
+	}
 	err = c.invoker.Get("/getUserInfo.action", nil, &space)
 	return
 }
@@ -21,6 +25,9 @@ type result struct {
 }
 
 func (client *api) Sign() error {
+	if client.family != "" {
+		return errFamily
+	}
 	params := url.Values{}
 	var r result
 	err := client.invoker.Get("/mkt/userSign.action", params, &r)
@@ -43,7 +50,7 @@ type signResp struct {
 func (a *api) signReq(url string) {
 	var e signResp
 	req, _ := http.NewRequest(http.MethodGet, url, nil)
-	err := a.invoker.Do(req, &e, 3)
+	err := a.invoker.Do(req, &e)
 	if err == nil {
 		switch e.ErrorCode {
 		case "User_Not_Chance":
@@ -0,0 +1,175 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/gowsp/cloud189/pkg"
+)
+
+type taskType string
+
+const (
+	restoreTask taskType = "RESTORE"
+	clearTask   taskType = "CLEAR_RECYCLE"
+	emptyTask   taskType = "EMPTY_RECYCLE"
+	saveTask    taskType = "SHARE_SAVE"
+	copyTask    taskType = "COPY"
+	moveTask    taskType = "MOVE"
+	deleteTask  taskType = "DELETE"
+)
+
+type taskInfo struct {
+	FileId     string `json:"fileId"`
+	FileName   string `json:"fileName"`
+	IsFolder   int    `json:"isFolder"`
+	IsConflict int    `json:"isConflict,omitempty"`
+	// 1 skip, 2 keep both, 3 overwrite
+	DealWay int `json:"dealWay,omitempty"`
+}
+
+var dealWays = map[pkg.ConflictPolicy]int{
+	pkg.ConflictSkip:      1,
+	pkg.ConflictRename:    2,
+	pkg.ConflictOverwrite: 3,
+}
+
+type taskStatus struct {
+	pkg.Result
+	TaskId       string        `json:"taskId"`
+	TaskStatus   int           `json:"taskStatus"`
+	Process      int           `json:"process"`
+	SubTaskCount int           `json:"subTaskCount"`
+	Succeeded    int           `json:"successedCount"`
+	Failed       int           `json:"failedCount"`
+	Skipped      int           `json:"skipCount"`
+	SucceededIds []json.Number `json:"successedFileIdList"`
+}
+
+type conflictResp struct {
+	TaskInfos []struct {
+		FileId   json.Number `json:"fileId"`
+		FileName string      `json:"fileName"`
+		IsFolder int         `json:"isFolder"`
+	} `json:"taskInfos"`
+}
+
+type taskResp struct {
+	pkg.Result
+	TaskId string `json:"taskId"`
+}
+
+func (c *api) createTask(t taskType, target string, files ...pkg.File) (string, error) {
+	return c.submitTask(t, target, nil, files...)
+}
+
+func (c *api) submitTask(t taskType, target string, extra url.Values, files ...pkg.File) (string, error) {
+	infos := make([]taskInfo, len(files))
+	for i, f := range files {
+		infos[i] = taskInfo{FileId: f.Id(), FileName: f.Name()}
+		if f.IsDir() {
+			infos[i].IsFolder = 1
+		}
+	}
+	data, err := json.Marshal(infos)
+	if err != nil {
+		return "", err
+	}
+	params := make(url.Values)
+	for k, v := range extra {
+		params[k] = v
+	}
+	params.Set("type", string(t))
+	params.Set("taskInfos", string(data))
+	params.Set("targetFolderId", target)
+	if c.family != "" {
+		params.Set("familyId", c.family)
+	}
+	var result taskResp
+	if err = c.invoker.Post("/batch/createBatchTask.action", params, &result); err != nil {
+		return "", err
+	}
+	if err = result.Err("/batch/createBatchTask.action"); err != nil {
+		return "", err
+	}
+	c.tasks.Store(result.TaskId, t)
+	return result.TaskId, nil
+}
+
+func (c *api) ResolveConflict(id string, target pkg.File, policy pkg.ConflictPolicy, files ...pkg.TaskFile) error {
+	t, ok := c.tasks.Load(id)
+	if !ok {
+		return fmt.Errorf("task %s is not submitted by this client", id)
+	}
+	dealWay, ok := dealWays[policy]
+	if !ok {
+		return fmt.Errorf("task %s: %d files conflict with existing ones", id, len(files))
+	}
+	infos := make([]taskInfo, len(files))
+	for i, f := range files {
+		infos[i] = taskInfo{FileId: f.Id, FileName: f.Name, IsConflict: 1, DealWay: dealWay}
+		if f.IsDir {
+			infos[i].IsFolder = 1
+		}
+	}
+	data, err := json.Marshal(infos)
+	if err != nil {
+		return err
+	}
+	params := make(url.Values)
+	params.Set("taskId", id)
+	params.Set("type", string(t.(taskType)))
+	params.Set("targetFolderId", target.Id())
+	params.Set("taskInfos", string(data))
+	if c.family != "" {
+		params.Set("familyId", c.family)
+	}
+	var result taskResp
+	if err = c.invoker.Post("/batch/manageBatchTask.action", params, &result); err != nil {
+		return err
+	}
+	return result.Err("/batch/manageBatchTask.action")
+}
+
+func (c *api) CheckTask(id string) (*pkg.TaskInfo, error) {
+	params := url.Values{"taskId": {id}}
+	var result taskStatus
+	if err := c.invoker.Post("/batch/checkBatchTask.action", params, &result); err != nil {
+		return nil, err
+	}
+	if err := result.Err("/batch/checkBatchTask.action"); err != nil {
+		return nil, err
+	}
+	info := &pkg.TaskInfo{
+		Id:        id,
+		Status:    pkg.TaskStatus(result.TaskStatus),
+		Process:   result.Process,
+		Total:     result.SubTaskCount,
+		Succeeded: result.Succeeded,
+		Failed:    result.Failed,
+		Skipped:   result.Skipped,
+		Error:     result.ErrorCode,
+	}
+	if info.Status == pkg.TaskDone {
+		c.tasks.Delete(id)
+	}
+	for _, id := range result.SucceededIds {
+		info.SucceededIds = append(info.SucceededIds, id.String())
+	}
+	if info.Status == pkg.TaskConflict {
+		var conflict conflictResp
+		if err := c.invoker.Post("/batch/getConflictTaskInfo.action", params, &conflict); err != nil {
+			return nil, err
+		}
+		for _, f := range conflict.TaskInfos {
+			info.Conflicts = append(info.Conflicts, pkg.TaskFile{
+				Id:     f.FileId.String(),
+				Name:   f.FileName,
+				IsDir:  f.IsFolder == 1,
+				Result: pkg.TaskFileConflict,
+			})
+		}
+	}
+	return info, nil
+}
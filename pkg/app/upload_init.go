@@ -2,17 +2,12 @@ package app
 
 import (
 	"encoding/hex"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"log"
 	"net/http"
 	"net/url"
-	"os"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gowsp/cloud189/pkg"
 	"github.com/gowsp/cloud189/pkg/file"
@@ -23,13 +18,27 @@ import (
 type Upload struct {
 	session *invoker.Session
 	invoker *invoker.Invoker
+	family  string
 }
 
 func (client *api) Uploader() pkg.ReadWriter {
 	client.invoker.Get("/keepUserSession.action", nil, "")
-	return &Upload{session: client.conf.Session, invoker: client.invoker}
+	return &Upload{session: client.conf.Session, invoker: client.invoker, family: client.family}
 }
-func (client *Upload) Write(upload pkg.Upload) error {
+
+// path returns the upload path of personal or family space
+func (up *Upload) path(name string) string {
+	if up.family == "" {
+		return "/person/" + name
+	}
+	return "/family/" + name
+}
+func (client *Upload) Write(upload pkg.Upload) (err error) {
+	t := pkg.NewTransfer(pkg.ProgressOf(upload), pkg.OpUpload, upload.Name(), upload.Size(), upload.SliceNum())
+	t.Start(0)
+	defer func() {
+		t.Finish(err)
+	}()
 	data, err := client.init(upload)
 	if err != nil {
 		return err
@@ -49,7 +58,7 @@ func (client *Upload) Write(upload pkg.Upload) error {
 	if err != nil {
 		return err
 	}
-	err = rsp.upload(upload, parts)
+	err = rsp.upload(client.invoker, t, parts)
 	if err != nil {
 		return err
 	}
@@ -62,43 +71,24 @@ func (up *Upload) encrypt(f url.Values) string {
 	return hex.EncodeToString(data)
 }
 
-func (up *Upload) do(req *http.Request, retry int, result any) error {
-	resp, err := up.invoker.DoWithResp(req)
-	if err != nil {
-		return err
-	}
-	if resp.StatusCode == 200 {
-		defer resp.Body.Close()
-		return json.NewDecoder(resp.Body).Decode(&result)
-	}
-	var e uperror
-	json.NewDecoder(resp.Body).Decode(&e)
-	if e.Code == "UserDayFlowOverLimited" {
-		return errors.New("上传超过当日流量限制")
-	}
-	if retry > 5 {
-		return err
-	}
-	time.Sleep(time.Second)
-	return up.do(req, retry+1, result)
-}
-
-type uperror struct {
-	Code string `json:"code"`
-	Msg  string `json:"msg"`
-}
-
 func (i *Upload) Get(path string, params url.Values, result any) error {
-	vals := make(url.Values)
-	vals.Set("params", i.encrypt(params))
-	req, err := http.NewRequest(http.MethodGet, "https://upload.cloud.189.cn"+path+"?"+vals.Encode(), nil)
-	if err != nil {
-		return err
+	err := i.invoker.Request(func() (*http.Request, error) {
+		// params are encrypted by session secret which changes after refresh
+		vals := make(url.Values)
+		vals.Set("params", i.encrypt(params))
+		req, err := http.NewRequest(http.MethodGet, "https://upload.cloud.189.cn"+path+"?"+vals.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("decodefields", "familyId,parentFolderId,fileName,fileMd5,fileSize,sliceMd5,sliceSize,albumId,extend,lazyCheck,isLog")
+		req.Header.Set("accept", "application/json;charset=UTF-8")
+		req.Header.Set("cache-control", "no-cache")
+		return req, nil
+	}, result)
+	if errors.Is(err, pkg.ErrDailyFlowLimit) {
+		return fmt.Errorf("上传超过当日流量限制: %w", err)
 	}
-	req.Header.Set("decodefields", "familyId,parentFolderId,fileName,fileMd5,fileSize,sliceMd5,sliceSize,albumId,extend,lazyCheck,isLog")
-	req.Header.Set("accept", "application/json;charset=UTF-8")
-	req.Header.Set("cache-control", "no-cache")
-	return i.do(req, 0, result)
+	return err
 }
 
 type uploadInfo struct {
@@ -135,8 +125,11 @@ func (c *Upload) init(i pkg.Upload) (*uploadInfo, error) {
 		params.Set("sliceMd5", i.SliceMD5())
 	}
 	params.Set("extend", `{"opScene":"1","relativepath":"","rootfolderid":""}`)
+	if c.family != "" {
+		params.Set("familyId", c.family)
+	}
 	var upload initResp
-	if err := c.Get("/person/initMultiUpload", params, &upload); err != nil {
+	if err := c.Get(c.path("initMultiUpload"), params, &upload); err != nil {
 		return nil, err
 	}
 	if upload.Data.UploadFileId == "" {
@@ -153,35 +146,31 @@ type uploadUrlResp struct {
 	} `json:"uploadUrls,omitempty"`
 }
 
-func (rsp *uploadUrlResp) upload(info pkg.Upload, parts []pkg.UploadPart) error {
-	print := os.Getenv("EXE_MODE") == "1"
-	if print {
-		log.Println("start upload", info.Name())
-	}
+func (rsp *uploadUrlResp) upload(client *invoker.Invoker, t *pkg.Transfer, parts []pkg.UploadPart) error {
 	for _, part := range parts {
 		num := strconv.Itoa(part.Num() + 1)
 		upload := rsp.Data["partNumber_"+num]
-		req, _ := http.NewRequest(http.MethodPut, upload.RequestURL, part.Data())
-		headers := strings.Split(upload.RequestHeader, "&")
-		for _, v := range headers {
-			i := strings.Index(v, "=")
-			req.Header.Set(v[0:i], v[i+1:])
-		}
-		if print {
-			log.Println("upload part", num)
-		}
-		resp, err := http.DefaultClient.Do(req)
+		body, size, err := file.PartBody(part)
 		if err != nil {
 			return err
 		}
-		resp.Body.Close()
-		if resp.StatusCode != 200 {
-			data, _ := io.ReadAll(resp.Body)
-			return fmt.Errorf("upload error %s", string(data))
+		body = t.Body(body)
+		err = client.SendRetry(func() (*http.Request, error) {
+			req, err := http.NewRequest(http.MethodPut, upload.RequestURL, pkg.Bandwidth.Reader(body()))
+			if err != nil {
+				return nil, err
+			}
+			req.ContentLength = size
+			for _, v := range strings.Split(upload.RequestHeader, "&") {
+				i := strings.Index(v, "=")
+				req.Header.Set(v[0:i], v[i+1:])
+			}
+			return req, nil
+		}, invoker.CheckStatus)
+		if err != nil {
+			return fmt.Errorf("upload part %s: %w", num, err)
 		}
-	}
-	if print {
-		log.Println("upload", info.Name(), "completed")
+		t.PartDone()
 	}
 	return nil
 }
@@ -191,7 +180,7 @@ func (client *Upload) getUploadUrl(fileId string, names []string) (*uploadUrlRes
 	p.Set("partInfo", strings.Join(names, ","))
 	p.Set("uploadFileId", fileId)
 	urlResp := new(uploadUrlResp)
-	return urlResp, client.Get("/person/getMultiUploadUrls", p, urlResp)
+	return urlResp, client.Get(client.path("getMultiUploadUrls"), p, urlResp)
 }
 
 type uploadResult struct {
@@ -221,5 +210,5 @@ func (client *Upload) commit(i pkg.Upload, fileId, lazyCheck string) error {
 	if i.Overwrite() {
 		params.Set("opertype", "3")
 	}
-	return client.Get("/person/commitMultiUploadFile", params, &result)
+	return client.Get(client.path("commitMultiUploadFile"), params, &result)
 }
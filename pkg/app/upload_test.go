@@ -1,9 +1,15 @@
 package app
 
 import (
+	"bytes"
+	"io"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/gowsp/cloud189/pkg"
 	"github.com/gowsp/cloud189/pkg/file"
 	"github.com/gowsp/cloud189/pkg/invoker"
 )
@@ -31,3 +37,51 @@ func TestNet(t *testing.T) {
 	e := u.Write(file)
 	log.Println(e)
 }
+
+type bufferPart struct {
+	num  int
+	data *bytes.Buffer
+}
+
+func (p *bufferPart) Num() int        { return p.num }
+func (p *bufferPart) Name() string    { return "part" }
+func (p *bufferPart) Data() io.Reader { return p.data }
+
+func TestUploadRetry(t *testing.T) {
+	puts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		puts++
+		if puts == 1 {
+			// the first part fails after its body is sent
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	t.Cleanup(server.Close)
+	client := invoker.NewInvoker(server.URL, func() error { return nil }, &invoker.Config{})
+	client.SetRetry(invoker.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond})
+
+	var last pkg.ProgressEvent
+	tr := pkg.NewTransfer(pkg.ProgressFunc(func(e pkg.ProgressEvent) { last = e }), pkg.OpUpload, "a.txt", 10, 2)
+	tr.Start(0)
+	parts := []pkg.UploadPart{
+		&bufferPart{num: 0, data: bytes.NewBufferString("hello")},
+		&bufferPart{num: 1, data: bytes.NewBufferString("world")},
+	}
+	rsp := &uploadUrlResp{Data: map[string]struct {
+		RequestURL    string `json:"requestURL,omitempty"`
+		RequestHeader string `json:"requestHeader,omitempty"`
+	}{
+		"partNumber_1": {RequestURL: server.URL, RequestHeader: "A=1"},
+		"partNumber_2": {RequestURL: server.URL, RequestHeader: "A=2"},
+	}}
+	if err := rsp.upload(client, tr, parts); err != nil {
+		t.Fatal(err)
+	}
+	if puts != 3 {
+		t.Fatalf("expect the first part retried, got %d puts", puts)
+	}
+	if last.Kind != pkg.ProgressPart || last.Done != last.Total {
+		t.Fatalf("retried bytes counted twice: %+v", last)
+	}
+}
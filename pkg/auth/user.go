@@ -0,0 +1,48 @@
+// Package auth defines users of the servers, kept in config by hashes of their passwords
+package auth
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Realm of servers, digest hashes of users are bound to it
+const Realm = "cloud189"
+
+// User is an account of webdav server, only hashes of the password are kept
+type User struct {
+	Name string `json:"name"`
+	// bcrypt hash of password, checked by basic auth
+	Hash string `json:"hash"`
+	// md5 of name:realm:password checked by digest auth, empty unless enabled,
+	// it is enough to pass digest auth so it is as sensitive as the password
+	Digest string `json:"digest,omitempty"`
+	// cloud dir served as root of the user, whole drive when empty
+	Root     string `json:"root,omitempty"`
+	ReadOnly bool   `json:"readOnly,omitempty"`
+}
+
+// NewUser returns user of name and password, digest enables digest auth of the user
+func NewUser(name, password string, digest bool) (*User, error) {
+	if name == "" || strings.ContainsAny(name, ":\"") {
+		return nil, fmt.Errorf("invalid webdav user name %q", name)
+	}
+	if password == "" {
+		return nil, errors.New("password of webdav user is empty")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	user := &User{Name: name, Hash: string(hash)}
+	if digest {
+		sum := md5.Sum([]byte(name + ":" + Realm + ":" + password))
+		user.Digest = hex.EncodeToString(sum[:])
+	}
+	return user, nil
+}
@@ -0,0 +1,7 @@
+package pkg
+
+import "github.com/gowsp/cloud189/pkg/util"
+
+// Bandwidth limits bodies of uploaded slices and downloaded files of this process,
+// unlimited by default
+var Bandwidth = util.NewLimiter()
@@ -10,20 +10,57 @@ type Drive interface {
 	fs.ReadDirFS
 	Space() (Space, error)
 	Mkdir(name string) error
-	Delete(name ...string) error
+	Delete(cfg TaskConfig, name ...string) error
 	QrLogin() error
 	Login(username, password string) error
 	Logout() error
-	Copy(target string, source ...string) error
-	Move(target string, source ...string) error
+	Copy(cfg TaskConfig, target string, source ...string) error
+	Move(cfg TaskConfig, target string, source ...string) error
 	Upload(config UploadConfig, cloud string, locals ...string) error
-	UploadFrom(file Upload) error
-	Download(local string, cloud ...string) error
-	Share(prifix, cloud string) (func(http.ResponseWriter, *http.Request), error)
+	UploadFrom(config UploadConfig, file Upload) error
+	Download(config DownloadConfig, local string, cloud ...string) error
+	Share(cfg ShareConfig, cloud string) (func(http.ResponseWriter, *http.Request), error)
 	GetDownloadUrl(cloud string) (string, error)
+	Glob(pattern string) ([]string, error)
 	// 新增方法
 	Rename(oldPath, newName string) error
 	Search(path, keyword string) ([]File, error)
+	// 回收站
+	Trash() ([]File, error)
+	Restore(name ...string) error
+	Purge(name ...string) error
+	EmptyTrash() error
+	// 分享链接
+	CreateLink(cloud string, expire int, withCode bool) (*ShareLink, error)
+	Links() ([]ShareLink, error)
+	CancelLink(id ...string) error
+	// 转存他人分享
+	Import(share *ShareInfo, target string, file ...File) error
+	// 个人云与家庭云间复制
+	CopyTo(cfg TaskConfig, space Drive, target string, source ...string) error
+	// 批量任务状态
+	Task(id string) (*TaskInfo, error)
+}
+
+// ConflictApi is implemented by DriveApi which resolves conflicts of a paused batch task
+type ConflictApi interface {
+	// apply policy to the conflicting files of task moving or copying into target
+	ResolveConflict(id string, target File, policy ConflictPolicy, files ...TaskFile) error
+}
+
+// HashFile is implemented by File knowing md5 of its content
+type HashFile interface {
+	FileMD5() string
+}
+
+// FamilyApi is implemented by DriveApi bound to a family cloud
+type FamilyApi interface {
+	// family cloud id, empty for personal space
+	FamilyId() string
+	// copy family files into personal dir
+	SaveToPerson(target File, source ...File) (string, error)
+	// copy personal files into family dir
+	SaveFromPerson(target File, source ...File) (string, error)
 }
 
 type FileType uint16
@@ -82,12 +119,39 @@ type DriveApi interface {
 	// rename file
 	Rename(target File, name string) error
 
-	// move file
-	Move(target File, source ...File) error
+	// move file, returns batch task id
+	Move(target File, source ...File) (string, error)
+
+	// copy file, returns batch task id
+	Copy(target File, source ...File) (string, error)
+
+	// delete file, returns batch task id
+	Delete(file ...File) (string, error)
+
+	// check status of batch task
+	CheckTask(id string) (*TaskInfo, error)
+
+	// list recycle bin
+	ListRecycle() ([]File, error)
+
+	// restore file from recycle bin to its original path
+	Restore(file ...File) error
+
+	// delete file from recycle bin
+	DeleteRecycle(file ...File) error
+
+	// empty recycle bin
+	EmptyRecycle() error
+
+	// create share link, expire in days, 0 means never expires
+	CreateShare(file File, expire int, withCode bool) (*ShareLink, error)
+
+	// list created share links
+	ListShare() ([]ShareLink, error)
 
-	// copy file
-	Copy(target File, source ...File) error
+	// cancel share links
+	CancelShare(id ...string) error
 
-	// delete file
-	Delete(file ...File) error
+	// save files of other's share into target dir
+	SaveShare(share *ShareInfo, target File, file ...File) error
 }
@@ -5,28 +5,26 @@ import (
 	"time"
 
 	"github.com/gowsp/cloud189/pkg"
-	"github.com/gowsp/cloud189/pkg/file"
 )
 
-var nodes sync.Map
-
-func load(id string) *node {
-	if val, ok := nodes.Load(id); ok {
+func (f *FS) load(id string) *node {
+	if val, ok := f.nodes.Load(id); ok {
 		return val.(*node)
 	}
-	if id == file.Root.Id() {
-		return newNode(file.Root)
+	if id == f.root.Id() {
+		return f.newNode(f.root)
 	}
 	return nil
 }
 
-func newNode(file pkg.File) *node {
-	node := &node{info: file}
-	nodes.Store(file.Id(), node)
+func (f *FS) newNode(file pkg.File) *node {
+	node := &node{fs: f, info: file}
+	f.nodes.Store(file.Id(), node)
 	return node
 }
 
 type node struct {
+	fs     *FS
 	info   pkg.File
 	node   sync.Map
 	exp    time.Time
@@ -45,7 +43,7 @@ func (n *node) enable() {
 }
 func (n *node) add(children ...pkg.File) {
 	for _, child := range children {
-		node := newNode(child)
+		node := n.fs.newNode(child)
 		n.node.Store(child.Name(), node)
 	}
 }
@@ -80,23 +78,23 @@ func (n *node) list(loader func() ([]pkg.File, error)) ([]pkg.File, error) {
 }
 
 func (n *node) delete(child pkg.File) {
-	p := load(child.Id())
+	p := n.fs.load(child.Id())
 	if child.IsDir() && p != nil {
 		p.node.Range(func(key, value any) bool {
 			p.delete(value.(*node).info)
 			return true
 		})
 	}
-	nodes.Delete(child.Id())
+	n.fs.nodes.Delete(child.Id())
 	n.node.Delete(child.Name())
 	n.loaded = false
 }
-func invalid(files ...pkg.File) {
+func (f *FS) invalid(files ...pkg.File) {
 	for _, file := range files {
 		if file == nil {
 			continue
 		}
-		node := load(file.PId())
+		node := f.load(file.PId())
 		if node == nil {
 			continue
 		}
@@ -0,0 +1,144 @@
+package drive
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/file"
+)
+
+// freeName returns name suffixed by number which does not exist in dir
+func (f *FS) freeName(dir pkg.File, name string) (string, error) {
+	children, err := f.api.List(dir, pkg.ALL)
+	if err != nil {
+		return "", err
+	}
+	exists := make(map[string]pkg.File, len(children))
+	for _, child := range children {
+		exists[child.Name()] = child
+	}
+	return nextName(exists, name), nil
+}
+
+// nextName returns name (n).ext not in exists
+func nextName(exists map[string]pkg.File, name string) string {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, i, ext)
+		if _, ok := exists[candidate]; !ok {
+			return candidate
+		}
+	}
+}
+
+// uploadPolicy applies conflict policy to uploads by listings of their cloud dirs
+type uploadPolicy struct {
+	fs     *FS
+	policy pkg.ConflictPolicy
+	mu     sync.Mutex
+	// parent id -> name -> file
+	dirs map[string]map[string]pkg.File
+}
+
+func (f *FS) uploadPolicy(policy pkg.ConflictPolicy) *uploadPolicy {
+	return &uploadPolicy{fs: f, policy: policy, dirs: make(map[string]map[string]pkg.File)}
+}
+
+func (p *uploadPolicy) children(parentId string) (map[string]pkg.File, error) {
+	if dir, ok := p.dirs[parentId]; ok {
+		return dir, nil
+	}
+	parent := &file.FileInfo{FileId: json.Number(parentId), IsFolder: true}
+	children, err := p.fs.api.List(parent, pkg.ALL)
+	if err != nil {
+		return nil, err
+	}
+	dir := make(map[string]pkg.File, len(children))
+	for _, child := range children {
+		dir[child.Name()] = child
+	}
+	p.dirs[parentId] = dir
+	return dir, nil
+}
+
+// apply returns the upload to write, nil when it is skipped
+func (p *uploadPolicy) apply(up pkg.Upload) (pkg.Upload, error) {
+	if p.policy == "" {
+		return up, nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	dir, err := p.children(up.ParentId())
+	if err != nil {
+		return nil, err
+	}
+	name := up.Name()
+	old, ok := dir[name]
+	if !ok {
+		dir[name] = &file.FileInfo{FileName: name}
+		return up, nil
+	}
+	if !old.IsDir() && p.policy != pkg.ConflictFail && sameContent(old, up) {
+		return nil, nil
+	}
+	switch p.policy {
+	case pkg.ConflictSkip:
+		return nil, nil
+	case pkg.ConflictRename:
+		name = nextName(dir, name)
+		dir[name] = &file.FileInfo{FileName: name}
+		return &conflictUpload{Upload: up, name: name}, nil
+	case pkg.ConflictNewer:
+		if !old.IsDir() {
+			mod := modTime(up)
+			if mod.IsZero() {
+				return nil, fmt.Errorf("%s: %w in cloud dir, modification time of upload is unknown", name, fs.ErrExist)
+			}
+			if !mod.After(old.ModTime()) {
+				return nil, nil
+			}
+		}
+		fallthrough
+	case pkg.ConflictOverwrite:
+		if old.IsDir() {
+			return nil, fmt.Errorf("%s: is a directory in cloud", name)
+		}
+		return &conflictUpload{Upload: up, name: name, overwrite: true}, nil
+	}
+	return nil, fmt.Errorf("%s: %w in cloud dir", name, fs.ErrExist)
+}
+
+// sameContent compares size and md5, the md5 of lazy checked upload is unknown before uploading
+func sameContent(old pkg.File, up pkg.Upload) bool {
+	info, ok := old.(pkg.HashFile)
+	if !ok || info.FileMD5() == "" || up.LazyCheck() || old.Size() != up.Size() {
+		return false
+	}
+	return strings.EqualFold(info.FileMD5(), up.FileMD5())
+}
+
+// modTime returns modification time of the upload, zero when it is unknown such as request bodies
+func modTime(up pkg.Upload) time.Time {
+	if m, ok := up.(interface{ ModTime() time.Time }); ok {
+		return m.ModTime()
+	}
+	return time.Time{}
+}
+
+// conflictUpload uploads under another name or overwrites the existing one
+type conflictUpload struct {
+	pkg.Upload
+	name      string
+	overwrite bool
+}
+
+func (u *conflictUpload) Name() string       { return u.name }
+func (u *conflictUpload) Overwrite() bool    { return u.overwrite }
+func (u *conflictUpload) Unwrap() pkg.Upload { return u.Upload }
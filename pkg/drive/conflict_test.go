@@ -0,0 +1,88 @@
+package drive
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/file"
+)
+
+type memUpload struct {
+	pkg.Upload
+	name string
+	size int64
+	md5  string
+	mod  time.Time
+}
+
+func (u *memUpload) ParentId() string   { return "d1" }
+func (u *memUpload) Name() string       { return u.name }
+func (u *memUpload) Size() int64        { return u.size }
+func (u *memUpload) FileMD5() string    { return u.md5 }
+func (u *memUpload) LazyCheck() bool    { return false }
+func (u *memUpload) Overwrite() bool    { return false }
+func (u *memUpload) ModTime() time.Time { return u.mod }
+
+func TestUploadPolicy(t *testing.T) {
+	api := &memApi{}
+	api.add("d1", "-11", "dir", true)
+	api.add("f1", "d1", "a.txt", false)
+	api.add("f2", "d1", "a (1).txt", false)
+	api.files[1].FileSize = 3
+	api.files[1].MD5 = "ABC"
+	api.files[1].FileModTime = file.ModTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	f := New(api).(*FS)
+
+	changed := &memUpload{name: "a.txt", size: 4, md5: "def", mod: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+	same := &memUpload{name: "a.txt", size: 3, md5: "abc"}
+	fresh := &memUpload{name: "b.txt"}
+	newer := &memUpload{name: "a.txt", size: 4, md5: "def", mod: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	// request bodies have no modification time
+	unknown := &memUpload{name: "a.txt", size: 4, md5: "def"}
+
+	tests := []struct {
+		policy pkg.ConflictPolicy
+		up     pkg.Upload
+		name   string
+		over   bool
+		err    error
+	}{
+		{"", changed, "a.txt", false, nil},
+		{pkg.ConflictFail, changed, "", false, fs.ErrExist},
+		{pkg.ConflictSkip, changed, "", false, nil},
+		{pkg.ConflictOverwrite, changed, "a.txt", true, nil},
+		{pkg.ConflictOverwrite, same, "", false, nil},
+		{pkg.ConflictRename, changed, "a (2).txt", false, nil},
+		{pkg.ConflictNewer, changed, "", false, nil},
+		{pkg.ConflictNewer, newer, "a.txt", true, nil},
+		{pkg.ConflictNewer, unknown, "", false, fs.ErrExist},
+		{pkg.ConflictNewer, pkg.WithModTime(unknown, newer.mod), "a.txt", true, nil},
+		{pkg.ConflictSkip, fresh, "b.txt", false, nil},
+	}
+	for _, tt := range tests {
+		up, err := f.uploadPolicy(tt.policy).apply(tt.up)
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("%s %s: unexpected error %v", tt.policy, tt.up.Name(), err)
+		}
+		if tt.name == "" {
+			if up != nil {
+				t.Fatalf("%s %s: should be skipped", tt.policy, tt.up.Name())
+			}
+			continue
+		}
+		if up.Name() != tt.name || up.Overwrite() != tt.over {
+			t.Fatalf("%s %s: got %s overwrite %v", tt.policy, tt.up.Name(), up.Name(), up.Overwrite())
+		}
+	}
+
+	// names reserved in the same batch are not reused
+	policy := f.uploadPolicy(pkg.ConflictRename)
+	first, _ := policy.apply(changed)
+	second, _ := policy.apply(changed)
+	if first.Name() != "a (2).txt" || second.Name() != "a (3).txt" {
+		t.Fatalf("rename in batch: %s %s", first.Name(), second.Name())
+	}
+}
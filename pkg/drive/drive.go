@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"path"
 	"sync"
 
 	"github.com/gowsp/cloud189/pkg"
@@ -14,10 +15,55 @@ func New(api pkg.DriveApi) pkg.Drive {
 	return &FS{api: api, root: file.Root}
 }
 
+// NewWithRoot creates a drive rooted at the given dir instead of the user root
+func NewWithRoot(api pkg.DriveApi, root pkg.File) pkg.Drive {
+	return &FS{api: api, root: root}
+}
+
+// Sub returns drive rooted at dir of d, paths of the returned drive cannot reach outside dir
+func Sub(d pkg.Drive, dir string) (pkg.Drive, error) {
+	s, ok := d.(interface {
+		Sub(dir string) (pkg.Drive, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("drive %T does not support sub dir", d)
+	}
+	return s.Sub(dir)
+}
+
+// Abs returns cloud path of name in d from root of the whole drive, which is name itself unless d is a sub drive
+func Abs(d pkg.Drive, name string) string {
+	if a, ok := d.(interface{ Abs(name string) string }); ok {
+		return a.Abs(name)
+	}
+	return path.Clean("/" + name)
+}
+
 type FS struct {
-	root  pkg.File
+	root pkg.File
+	// cloud path of root, empty for root of the whole drive
+	dir   string
 	api   pkg.DriveApi
 	share sync.Map
+	// cached dir tree of this drive, keyed by file id
+	nodes sync.Map
+}
+
+// Sub returns drive rooted at dir of this drive
+func (f *FS) Sub(dir string) (pkg.Drive, error) {
+	root, err := f.stat(path.Clean("/" + dir))
+	if err != nil {
+		return nil, err
+	}
+	if !root.IsDir() {
+		return nil, fmt.Errorf("%s is not a dir", dir)
+	}
+	return &FS{api: f.api, root: root, dir: f.Abs(dir)}, nil
+}
+
+// Abs returns cloud path of name from root of the whole drive
+func (f *FS) Abs(name string) string {
+	return path.Join("/", f.dir, path.Clean("/"+name))
 }
 
 func (f *FS) Login(username, password string) error {
@@ -52,40 +98,40 @@ func (f *FS) Mkdir(name string) error {
 	if !errors.Is(err, fs.ErrNotExist) {
 		return err
 	}
-	dir, err := f.api.Mkdir(file.Root, name)
+	dir, err := f.api.Mkdir(f.root, name)
 	if err != nil {
 		return err
 	}
-	invalid(dir)
+	f.invalid(dir)
 	return nil
 }
 
-func (f *FS) Copy(target string, source ...string) error {
+func (f *FS) Copy(cfg pkg.TaskConfig, target string, source ...string) error {
 	dest, err := f.stat(target)
 	if err != nil || !dest.IsDir() {
 		return fmt.Errorf("%s: file does not exist or not a directory", target)
 	}
-	src := f.resolve(source...)
+	src, err := f.resolve(source...)
 	if len(src) == 0 {
-		return nil
+		return err
 	}
 	defer func() {
-		load(dest.Id()).invalid()
-		invalid(src...)
+		f.load(dest.Id()).invalid()
+		f.invalid(src...)
 	}()
-	return f.api.Copy(dest, src...)
+	return errors.Join(f.copy(cfg, dest, src...), err)
 }
 
-func (f *FS) Delete(name ...string) error {
-	files := f.resolve(name...)
+func (f *FS) Delete(cfg pkg.TaskConfig, name ...string) error {
+	files, err := f.resolve(name...)
 	if len(files) == 0 {
-		return nil
+		return err
 	}
-	err := f.api.Delete(files...)
+	err = errors.Join(f.remove(cfg, files...), err)
 	for _, file := range files {
-		load(file.PId()).delete(file)
+		f.load(file.PId()).delete(file)
 	}
-	invalid(files...)
+	f.invalid(files...)
 	return err
 }
 
@@ -99,8 +145,8 @@ func (f *FS) Rename(oldPath, newName string) error {
 		return err
 	}
 	// 使缓存失效
-	load(file.PId()).invalid()
-	invalid(file)
+	f.load(file.PId()).invalid()
+	f.invalid(file)
 	return nil
 }
 
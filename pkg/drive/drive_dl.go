@@ -10,39 +10,47 @@ import (
 	"github.com/gowsp/cloud189/pkg"
 )
 
-func (f *FS) Download(local string, cloud ...string) error {
+func (f *FS) Download(cfg pkg.DownloadConfig, local string, cloud ...string) error {
 	info, err := os.Stat(local)
 	if err != nil {
 		return err
 	}
-	sources := f.resolve(cloud...)
+	sources, err := f.resolve(cloud...)
 	if len(sources) > 0 && !info.IsDir() {
 		return errors.New("local param need dir")
 	}
 	for _, source := range sources {
-		if err = f.download(info, local, source); err != nil {
-			fmt.Println(err)
+		if e := f.download(cfg, info, local, source); e != nil {
+			fmt.Println(e)
 		}
 	}
-	return nil
+	return err
 }
 
-func (f *FS) download(info os.FileInfo, local string, source pkg.File) error {
+func (f *FS) download(cfg pkg.DownloadConfig, info os.FileInfo, local string, source pkg.File) (err error) {
 	if info.IsDir() {
 		local = path.Join(local, source.Name())
 	}
+	if source.IsDir() {
+		return f.downloadDir(cfg, local, source)
+	}
+	t := pkg.NewTransfer(cfg.Progress, pkg.OpDownload, source.Name(), source.Size(), 0)
+	defer func() {
+		t.Finish(err)
+	}()
 	d, err := os.OpenFile(local, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
 	if err != nil {
 		return err
 	}
 	defer d.Close()
 	info, err = d.Stat()
-	if info.Size() == source.Size() {
-		return nil
-	}
 	if err != nil {
 		return err
 	}
+	t.Start(info.Size())
+	if info.Size() == source.Size() {
+		return nil
+	}
 	resp, err := f.api.Download(source, info.Size())
 	if err != nil {
 		return err
@@ -51,6 +59,25 @@ func (f *FS) download(info os.FileInfo, local string, source pkg.File) error {
 		return errors.New("error download status code " + resp.Status)
 	}
 	defer resp.Body.Close()
-	io.Copy(d, resp.Body)
-	return nil
+	_, err = io.Copy(d, pkg.Bandwidth.Reader(t.Reader(resp.Body)))
+	return err
+}
+
+func (f *FS) downloadDir(cfg pkg.DownloadConfig, local string, dir pkg.File) error {
+	if err := os.MkdirAll(local, 0755); err != nil {
+		return err
+	}
+	info, err := os.Stat(local)
+	if err != nil {
+		return err
+	}
+	children, err := f.list(dir)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, child := range children {
+		errs = append(errs, f.download(cfg, info, local, child))
+	}
+	return errors.Join(errs...)
 }
@@ -1,25 +1,170 @@
 package drive
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
 	"io/fs"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/gowsp/cloud189/pkg"
 )
 
+// read-ahead buffer of a file stream, forward seeks within it skip bytes instead of a new request
+const readAhead = 1 << 20
+
 func (f *FS) NewFile(info pkg.File) fs.File {
 	if info.IsDir() {
 		return &DirFile{info: info}
 	}
-	return &File{info: info}
+	return &File{api: f.api, info: info}
 }
 
+// File reads cloud file by ranged requests, the stream is opened on the first read after seek
 type File struct {
+	mu   sync.Mutex
+	api  pkg.DriveApi
 	info pkg.File
+	// offset of next read
+	pos int64
+	// download url resolved by api, reused by later ranges until it expires
+	url  *url.URL
+	body io.ReadCloser
+	buf  *bufio.Reader
+	// offset of the stream, which is ahead of pos after a short forward seek
+	at int64
 }
 
 func (a *File) Stat() (fs.FileInfo, error) { return a.info, nil }
-func (a *File) Read([]byte) (int, error)   { return 0, nil }
-func (a *File) Close() error               { return nil }
+
+func (a *File) Read(p []byte) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.pos >= a.info.Size() {
+		return 0, io.EOF
+	}
+	if err := a.open(); err != nil {
+		return 0, err
+	}
+	n, err := a.buf.Read(p)
+	a.pos += int64(n)
+	a.at = a.pos
+	if err == io.EOF && a.pos < a.info.Size() {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		a.close()
+	}
+	return n, err
+}
+
+func (a *File) Seek(offset int64, whence int) (int64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += a.pos
+	case io.SeekEnd:
+		offset += a.info.Size()
+	default:
+		return 0, errors.New("seek: invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("seek: negative position")
+	}
+	a.pos = offset
+	return offset, nil
+}
+
+func (a *File) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.close()
+	return nil
+}
+
+// open makes the stream start at pos, a short forward gap is skipped on the current stream
+func (a *File) open() error {
+	if a.body != nil {
+		gap := a.pos - a.at
+		if gap == 0 {
+			return nil
+		}
+		if gap > 0 && gap <= readAhead {
+			if _, err := a.buf.Discard(int(gap)); err == nil {
+				a.at = a.pos
+				return nil
+			}
+		}
+		a.close()
+	}
+	resp, err := a.fetch()
+	if err != nil {
+		return err
+	}
+	body := resp.Body
+	if resp.StatusCode == http.StatusOK && a.pos > 0 {
+		// range is ignored by server
+		if _, err = io.CopyN(io.Discard, body, a.pos); err != nil {
+			body.Close()
+			return err
+		}
+	}
+	a.body, a.at = body, a.pos
+	a.buf = bufio.NewReaderSize(pkg.Bandwidth.Reader(body), readAhead)
+	return nil
+}
+
+// fetch requests range from pos by the resolved url, or by api when it is unknown or expired,
+// the resolved url is tried once without retry, any failure falls back to api which retries
+func (a *File) fetch() (*http.Response, error) {
+	if a.url != nil && !expired(a.url) {
+		req, err := http.NewRequest(http.MethodGet, a.url.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", a.pos))
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil && resp.StatusCode < 300 {
+			return resp, nil
+		}
+		if err == nil {
+			resp.Body.Close()
+		}
+	}
+	resp, err := a.api.Download(a.info, a.pos)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("download %s: %s", a.info.Name(), resp.Status)
+	}
+	a.url = resp.Request.URL
+	return resp, nil
+}
+
+func (a *File) close() {
+	if a.body != nil {
+		a.body.Close()
+		a.body, a.buf = nil, nil
+	}
+}
+
+// expired reports whether the Expires of signed download url has passed
+func expired(u *url.URL) bool {
+	expires, err := strconv.ParseInt(u.Query().Get("Expires"), 10, 0)
+	if err != nil {
+		return false
+	}
+	return expires <= time.Now().Unix()
+}
 
 type DirFile struct {
 	info pkg.File
@@ -0,0 +1,83 @@
+package drive
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gowsp/cloud189/pkg"
+)
+
+// rangeApi serves file content at a download url
+type rangeApi struct {
+	memApi
+	url string
+	// calls of Download, which resolve the url
+	calls int
+}
+
+func (m *rangeApi) Download(file pkg.File, start int64) (*http.Response, error) {
+	m.calls++
+	req, _ := http.NewRequest(http.MethodGet, m.url+"/"+file.Id(), nil)
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", start))
+	return http.DefaultClient.Do(req)
+}
+
+func TestFileRange(t *testing.T) {
+	content := strings.Repeat("0123456789", 300000)
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "a.txt", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+
+	api := &rangeApi{url: server.URL}
+	api.add("f1", "-11", "a.txt", false)
+	api.files[0].FileSize = int64(len(content))
+	f := New(api).(*FS)
+
+	open, err := f.Open("/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer open.Close()
+	r := open.(io.ReadSeeker)
+	if _, err = r.Seek(-10, io.SeekEnd); err != nil {
+		t.Fatal(err)
+	}
+	tail, err := io.ReadAll(r)
+	if err != nil || string(tail) != "0123456789" {
+		t.Fatalf("tail %q, %v", tail, err)
+	}
+	if len(ranges) == 0 || ranges[0] != fmt.Sprintf("bytes=%d-", len(content)-10) {
+		t.Errorf("range of tail %v", ranges)
+	}
+
+	r.Seek(100, io.SeekStart)
+	buf := make([]byte, 5)
+	io.ReadFull(r, buf)
+	// short forward seek reuses the stream
+	r.Seek(1000, io.SeekCurrent)
+	io.ReadFull(r, buf)
+	if string(buf) != content[1105:1110] {
+		t.Errorf("read after forward seek %q", buf)
+	}
+	if len(ranges) != 2 || ranges[1] != "bytes=100-" {
+		t.Errorf("ranges %v", ranges)
+	}
+	if api.calls != 1 {
+		t.Errorf("download url resolved %d times", api.calls)
+	}
+
+	r.Seek(0, io.SeekStart)
+	all, err := io.ReadAll(r)
+	if err != nil || !bytes.Equal(all, []byte(content)) {
+		t.Errorf("read all %d bytes, %v", len(all), err)
+	}
+}
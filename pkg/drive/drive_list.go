@@ -1,6 +1,7 @@
 package drive
 
 import (
+	"errors"
 	"io/fs"
 	"strings"
 
@@ -10,15 +11,17 @@ import (
 func (f *FS) Stat(name string) (fs.FileInfo, error) {
 	return f.stat(name)
 }
-func (f *FS) resolve(path ...string) (files []pkg.File) {
+func (f *FS) resolve(path ...string) (files []pkg.File, err error) {
+	var errs []error
 	for _, path := range path {
-		file, err := f.stat(path)
+		matched, err := f.expand(path)
 		if err != nil {
+			errs = append(errs, err)
 			continue
 		}
-		files = append(files, file)
+		files = append(files, matched...)
 	}
-	return
+	return files, errors.Join(errs...)
 }
 func (f *FS) stat(name string) (pkg.File, error) {
 	var err error
@@ -37,7 +40,7 @@ func (f *FS) stat(name string) (pkg.File, error) {
 	return f.search(file, pkg.ALL, path[size])
 }
 func (f *FS) search(parent pkg.File, fileType pkg.FileType, name string) (pkg.File, error) {
-	return load(parent.Id()).search(name, func() (pkg.File, error) {
+	return f.load(parent.Id()).search(name, func() (pkg.File, error) {
 		entry, err := f.api.Search(parent, fileType, name)
 		if err != nil {
 			return nil, err
@@ -56,9 +59,7 @@ func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
 	if err != nil {
 		return nil, err
 	}
-	info, err := load(dir.Id()).list(func() ([]pkg.File, error) {
-		return f.api.List(dir, pkg.ALL)
-	})
+	info, err := f.list(dir)
 	if err != nil {
 		return nil, err
 	}
@@ -68,3 +69,9 @@ func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
 	}
 	return result, nil
 }
+
+func (f *FS) list(dir pkg.File) ([]pkg.File, error) {
+	return f.load(dir.Id()).list(func() ([]pkg.File, error) {
+		return f.api.List(dir, pkg.ALL)
+	})
+}
@@ -1,58 +1,123 @@
 package drive
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/fs"
-	"path/filepath"
+	"path"
+
+	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/file"
 )
 
-func (f *FS) Move(target string, source ...string) error {
+func (f *FS) Move(cfg pkg.TaskConfig, target string, source ...string) error {
 	if len(source) == 1 {
-		return f.singleMove(target, source[0])
+		if _, err := f.stat(source[0]); err == nil || !hasMeta(source[0]) {
+			return f.singleMove(cfg, target, source[0])
+		}
 	}
-	return f.multiMove(target, source...)
+	return f.multiMove(cfg, target, source...)
 }
 
-func (f *FS) singleMove(target string, sources string) error {
-	files := f.resolve(sources)
+func (f *FS) singleMove(cfg pkg.TaskConfig, target string, sources string) error {
+	files, err := f.resolve(sources)
 	if len(files) == 0 {
-		return fs.ErrNotExist
+		return err
 	}
 	source := files[0]
 	dest, err := f.stat(target)
 	defer func() {
-		invalid(source, dest)
+		f.invalid(source, dest)
 	}()
-	if err == nil {
-		if dest.IsDir() {
-			return f.api.Move(dest, files...)
+	if err == nil && dest.IsDir() {
+		return f.move(cfg, dest, files...)
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	dir, name := path.Split(target)
+	parent, err := f.stat(dir)
+	if err != nil {
+		return err
+	}
+	if dest != nil {
+		if dest.Id() == source.Id() {
+			return nil
 		}
-		f.api.Delete(dest)
-		if source.PId() == dest.PId() {
-			return f.api.Rename(source, dest.Name())
-		} else {
-			f.api.Move(dest, files...)
-			return f.api.Rename(source, dest.Name())
+		switch cfg.Policy() {
+		case pkg.ConflictFail:
+			return fmt.Errorf("%s: %w", target, fs.ErrExist)
+		case pkg.ConflictSkip:
+			return nil
+		case pkg.ConflictRename:
+			if name, err = f.freeName(parent, name); err != nil {
+				return err
+			}
+			dest = nil
 		}
 	}
-	if errors.Is(err, fs.ErrNotExist) {
-		dir, name := filepath.Split(target)
-		parent, err := f.stat(dir)
+	if source.Name() == name && source.PId() != parent.Id() {
+		return f.move(cfg, parent, source)
+	}
+	if source.PId() != parent.Id() {
+		// the source moves under a name free in both dirs, so that only dest is subject to the policy
+		moving, err := f.movingName(source, parent, name, dest == nil)
 		if err != nil {
 			return err
 		}
-		if err := f.api.Move(parent, source); err != nil {
+		if moving != source.Name() {
+			if err = f.api.Rename(source, moving); err != nil {
+				return err
+			}
+			source = &renamedFile{File: source, name: moving}
+		}
+		// rename after move needs the move finished
+		if err = f.move(pkg.TaskConfig{Timeout: cfg.Timeout, Progress: cfg.Progress}, parent, source); err != nil {
 			return err
 		}
-		if source.Name() == name {
+		if moving == name {
 			return nil
 		}
-		return f.api.Rename(source, name)
 	}
-	return err
+	if dest != nil {
+		// overwrite, the old one goes to recycle bin once the source is in place
+		if err := f.remove(pkg.TaskConfig{Timeout: cfg.Timeout}, dest); err != nil {
+			return err
+		}
+	}
+	return f.api.Rename(source, name)
+}
+
+// movingName returns name of source while it moves into dir, name itself when it is free in both dirs
+func (f *FS) movingName(source, dir pkg.File, name string, free bool) (string, error) {
+	exists := make(map[string]pkg.File)
+	for _, id := range []string{source.PId(), dir.Id()} {
+		children, err := f.api.List(&file.FileInfo{FileId: json.Number(id), IsFolder: true}, pkg.ALL)
+		if err != nil {
+			return "", err
+		}
+		for _, child := range children {
+			if child.Id() != source.Id() {
+				exists[child.Name()] = child
+			}
+		}
+	}
+	if _, ok := exists[name]; free && !ok {
+		return name, nil
+	}
+	return nextName(exists, name), nil
+}
+
+// renamedFile is a file renamed after it was listed
+type renamedFile struct {
+	pkg.File
+	name string
 }
-func (f *FS) multiMove(target string, source ...string) error {
+
+func (f *renamedFile) Name() string { return f.name }
+
+func (f *FS) multiMove(cfg pkg.TaskConfig, target string, source ...string) error {
 	dest, err := f.stat(target)
 	if err != nil {
 		return err
@@ -60,13 +125,13 @@ func (f *FS) multiMove(target string, source ...string) error {
 	if !dest.IsDir() {
 		return fmt.Errorf("target '%s' is not a directory", target)
 	}
-	files := f.resolve(source...)
+	files, err := f.resolve(source...)
 	defer func() {
-		load(dest.Id()).invalid()
-		invalid(files...)
+		f.load(dest.Id()).invalid()
+		f.invalid(files...)
 	}()
 	if len(files) == 0 {
-		return nil
+		return err
 	}
-	return f.api.Move(dest, files...)
+	return errors.Join(f.move(cfg, dest, files...), err)
 }
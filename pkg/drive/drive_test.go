@@ -43,7 +43,7 @@ func TestDecode(t *testing.T) {
 func TestDelete(t *testing.T) {
 	api := app.New(invoker.DefaultPath())
 	f := New(api)
-	err := f.Delete("/demo1")
+	err := f.Delete(pkg.TaskConfig{}, "/demo1")
 	fmt.Println(err)
 }
 func TestMakeDir(t *testing.T) {
@@ -69,7 +69,7 @@ func TestUpload(t *testing.T) {
 func TestDownload(t *testing.T) {
 	api := app.New(invoker.DefaultPath())
 	f := New(api)
-	err := f.Download("D:/", "/demo/page.html")
+	err := f.Download(pkg.DownloadConfig{}, "D:/", "/demo/page.html")
 	if err != nil {
 		log.Println(err)
 	}
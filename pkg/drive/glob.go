@@ -0,0 +1,158 @@
+package drive
+
+import (
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gowsp/cloud189/pkg"
+)
+
+type match struct {
+	path string
+	file pkg.File
+}
+
+// Glob returns the cloud paths matching pattern, supports *, ?, [...] and **
+func (f *FS) Glob(pattern string) ([]string, error) {
+	matches, err := f.glob(pattern)
+	if err != nil || len(matches) == 0 {
+		return nil, err
+	}
+	result := make([]string, len(matches))
+	for i, m := range matches {
+		result[i] = m.path
+	}
+	return result, nil
+}
+
+func hasMeta(name string) bool {
+	return strings.ContainsAny(name, `*?[\`)
+}
+
+// expand resolves name as an existing path first, then as a pattern
+func (f *FS) expand(name string) ([]pkg.File, error) {
+	file, err := f.stat(name)
+	if err == nil {
+		return []pkg.File{file}, nil
+	}
+	if !hasMeta(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	matches, err := f.glob(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "match", Path: name, Err: err}
+	}
+	if len(matches) == 0 {
+		return nil, &fs.PathError{Op: "match", Path: name, Err: fs.ErrNotExist}
+	}
+	files := make([]pkg.File, len(matches))
+	for i, m := range matches {
+		files[i] = m.file
+	}
+	return files, nil
+}
+
+func (f *FS) glob(pattern string) ([]match, error) {
+	pattern = path.Clean("/" + pattern)
+	if pattern == "/" {
+		return []match{{path: "/", file: f.root}}, nil
+	}
+	segments := strings.Split(pattern[1:], "/")
+	for _, seg := range segments {
+		if _, err := path.Match(seg, ""); err != nil {
+			return nil, err
+		}
+	}
+	var result []match
+	if err := f.globDir(f.root, "/", segments, &result); err != nil {
+		return nil, err
+	}
+	seen := make(map[string]struct{}, len(result))
+	uniq := result[:0]
+	for _, m := range result {
+		if _, ok := seen[m.path]; ok {
+			continue
+		}
+		seen[m.path] = struct{}{}
+		uniq = append(uniq, m)
+	}
+	sort.Slice(uniq, func(i, j int) bool { return uniq[i].path < uniq[j].path })
+	return uniq, nil
+}
+
+func (f *FS) globDir(dir pkg.File, dirPath string, segments []string, result *[]match) error {
+	seg, rest := segments[0], segments[1:]
+	if seg == "**" {
+		if len(rest) == 0 {
+			return f.walk(dir, dirPath, result)
+		}
+		if err := f.globDir(dir, dirPath, rest, result); err != nil {
+			return err
+		}
+		children, err := f.list(dir)
+		if err != nil {
+			return err
+		}
+		for _, child := range children {
+			if child.IsDir() {
+				if err := f.globDir(child, path.Join(dirPath, child.Name()), segments, result); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	var children []pkg.File
+	if hasMeta(seg) {
+		list, err := f.list(dir)
+		if err != nil {
+			return err
+		}
+		for _, child := range list {
+			if ok, _ := path.Match(seg, child.Name()); ok {
+				children = append(children, child)
+			}
+		}
+	} else {
+		child, err := f.search(dir, pkg.ALL, seg)
+		if err == fs.ErrNotExist {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		children = append(children, child)
+	}
+	for _, child := range children {
+		name := path.Join(dirPath, child.Name())
+		if len(rest) == 0 {
+			*result = append(*result, match{path: name, file: child})
+			continue
+		}
+		if child.IsDir() {
+			if err := f.globDir(child, name, rest, result); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (f *FS) walk(dir pkg.File, dirPath string, result *[]match) error {
+	children, err := f.list(dir)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		name := path.Join(dirPath, child.Name())
+		*result = append(*result, match{path: name, file: child})
+		if child.IsDir() {
+			if err := f.walk(child, name, result); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
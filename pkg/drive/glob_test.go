@@ -0,0 +1,122 @@
+package drive
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+
+	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/file"
+)
+
+type memApi struct {
+	pkg.DriveApi
+	files []*file.FileInfo
+}
+
+func (m *memApi) add(id, pid, name string, dir bool) {
+	m.files = append(m.files, &file.FileInfo{
+		FileId:   json.Number(id),
+		ParentId: json.Number(pid),
+		FileName: name,
+		IsFolder: dir,
+	})
+}
+func (m *memApi) List(parent pkg.File, fileType pkg.FileType) (result []pkg.File, err error) {
+	for _, f := range m.files {
+		if f.PId() == parent.Id() {
+			result = append(result, f)
+		}
+	}
+	return
+}
+func (m *memApi) Search(parent pkg.File, fileType pkg.FileType, name string) (result []pkg.File, err error) {
+	files, _ := m.List(parent, fileType)
+	for _, f := range files {
+		if f.Name() == name {
+			result = append(result, f)
+		}
+	}
+	return
+}
+
+func newGlobFS() *FS {
+	api := &memApi{}
+	api.add("g1", "-11", "backup", true)
+	api.add("g2", "g1", "2023-01.tar", false)
+	api.add("g3", "g1", "2023-02.tar", false)
+	api.add("g4", "g1", "2024-01.tar", false)
+	api.add("g5", "g1", "sub", true)
+	api.add("g6", "g5", "deep.tar", false)
+	api.add("g7", "-11", "photos", true)
+	api.add("g8", "g7", "a.jpg", false)
+	api.add("g9", "g7", "b.png", false)
+	api.add("g10", "g7", "[x].jpg", false)
+	return New(api).(*FS)
+}
+
+func TestGlob(t *testing.T) {
+	f := newGlobFS()
+	cases := map[string][]string{
+		"/backup/2023-*.tar":  {"/backup/2023-01.tar", "/backup/2023-02.tar"},
+		"/backup/202?-01.tar": {"/backup/2023-01.tar", "/backup/2024-01.tar"},
+		"/photos/[ab].*":      {"/photos/a.jpg", "/photos/b.png"},
+		"/photos/*.jpg":       {"/photos/[x].jpg", "/photos/a.jpg"},
+		"/**/*.tar":           {"/backup/2023-01.tar", "/backup/2023-02.tar", "/backup/2024-01.tar", "/backup/sub/deep.tar"},
+		"/backup/**":          {"/backup/2023-01.tar", "/backup/2023-02.tar", "/backup/2024-01.tar", "/backup/sub", "/backup/sub/deep.tar"},
+		"/*/sub/deep.tar":     {"/backup/sub/deep.tar"},
+		"/backup/*.zip":       nil,
+		"/missing/*.tar":      nil,
+	}
+	for pattern, want := range cases {
+		got, err := f.Glob(pattern)
+		if err != nil {
+			t.Fatalf("%s: %v", pattern, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", pattern, got, want)
+		}
+	}
+	if _, err := f.Glob("/backup/[a"); err == nil {
+		t.Error("expected bad pattern error")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	f := newGlobFS()
+	files, err := f.resolve("/photos/[x].jpg", "/backup/2023-*.tar", "/backup/*.zip")
+	if len(files) != 3 {
+		t.Fatalf("got %d files, want 3", len(files))
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("unmatched pattern not reported: %v", err)
+	}
+}
+
+func TestSub(t *testing.T) {
+	sub, err := Sub(newGlobFS(), "/backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := sub.Stat("/sub/deep.tar"); err != nil || info.Name() != "deep.tar" {
+		t.Errorf("stat in sub: %v, %v", info, err)
+	}
+	if _, err = sub.Stat("/photos"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("stat outside sub: %v", err)
+	}
+	if _, err = Sub(newGlobFS(), "/photos/a.jpg"); err == nil {
+		t.Error("sub of file")
+	}
+	deep, err := Sub(sub, "sub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := Abs(deep, "deep.tar"); name != "/backup/sub/deep.tar" {
+		t.Errorf("cloud path in nested sub: %s", name)
+	}
+	if name := Abs(newGlobFS(), "photos/../a.jpg"); name != "/a.jpg" {
+		t.Errorf("cloud path in drive: %s", name)
+	}
+}
@@ -0,0 +1,16 @@
+package drive
+
+import (
+	"fmt"
+
+	"github.com/gowsp/cloud189/pkg"
+)
+
+func (f *FS) Import(share *pkg.ShareInfo, target string, files ...pkg.File) error {
+	dest, err := f.stat(target)
+	if err != nil || !dest.IsDir() {
+		return fmt.Errorf("%s: file does not exist or not a directory", target)
+	}
+	defer f.load(dest.Id()).invalid()
+	return f.api.SaveShare(share, dest, files...)
+}
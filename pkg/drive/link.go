@@ -0,0 +1,21 @@
+package drive
+
+import (
+	"github.com/gowsp/cloud189/pkg"
+)
+
+func (f *FS) CreateLink(cloud string, expire int, withCode bool) (*pkg.ShareLink, error) {
+	file, err := f.stat(cloud)
+	if err != nil {
+		return nil, err
+	}
+	return f.api.CreateShare(file, expire, withCode)
+}
+
+func (f *FS) Links() ([]pkg.ShareLink, error) {
+	return f.api.ListShare()
+}
+
+func (f *FS) CancelLink(id ...string) error {
+	return f.api.CancelShare(id...)
+}
@@ -30,19 +30,37 @@ func (f *FS) GetDownloadUrl(cloud string) (string, error) {
 	return resp.Request.URL.String(), nil
 }
 
-func (f *FS) Share(prifix, cloud string) (func(http.ResponseWriter, *http.Request), error) {
+func (f *FS) Share(cfg pkg.ShareConfig, cloud string) (func(http.ResponseWriter, *http.Request), error) {
 	if _, err := f.stat(cloud); err != nil {
 		return nil, err
 	}
-	prifix = strings.TrimRight(prifix, "/")
+	prefix := strings.TrimRight(cfg.Prefix, "/")
+	var conns chan struct{}
+	if cfg.Proxy && cfg.ProxyConns > 0 {
+		conns = make(chan struct{}, cfg.ProxyConns)
+	}
 	return func(w http.ResponseWriter, r *http.Request) {
-		target := strings.TrimPrefix(r.RequestURI, prifix)
-		target = path.Join(cloud, target)
+		name := path.Clean("/" + strings.TrimPrefix(r.URL.Path, prefix))
+		auth, ok := shareAuth(cfg, r, name)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="cloud189"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		target := path.Join(cloud, name)
 		log.Println("request", target)
-		if f.shareFromCache(target, w) {
+		if !cfg.Proxy && f.shareFromCache(target, w) {
 			return
 		}
 		file, err := f.stat(target)
+		if err == nil && file.IsDir() {
+			f.shareDir(cfg, w, r, name, auth, file)
+			return
+		}
+		if err == nil && cfg.Proxy {
+			f.proxyShare(conns, target, file, w, r)
+			return
+		}
 		if err == nil {
 			f.doShare(target, file, w)
 			return
@@ -55,10 +73,6 @@ func (f *FS) Share(prifix, cloud string) (func(http.ResponseWriter, *http.Reques
 	}, nil
 }
 func (f *FS) doShare(target string, file pkg.File, w http.ResponseWriter) {
-	if file.IsDir() {
-		w.WriteHeader(204)
-		return
-	}
 	resp, err := f.api.Download(file, 0)
 	if err != nil {
 		writeError(w, err)
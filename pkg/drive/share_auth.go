@@ -0,0 +1,66 @@
+package drive
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gowsp/cloud189/pkg"
+)
+
+// SignShare returns query of a link to name in share valid until exp,
+// the link of a dir grants access to everything below it
+func SignShare(secret []byte, name string, exp time.Time) string {
+	name = path.Clean("/" + name)
+	v := url.Values{}
+	v.Set("exp", strconv.FormatInt(exp.Unix(), 10))
+	v.Set("sig", shareSign(secret, name, v.Get("exp")))
+	return v.Encode()
+}
+
+func shareSign(secret []byte, name, exp string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(exp + "\n" + name))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// shareAuth checks the request of name, query is the signature to keep in links of a listing
+func shareAuth(cfg pkg.ShareConfig, r *http.Request, name string) (query string, ok bool) {
+	if cfg.Token == "" && len(cfg.Secret) == 0 {
+		return "", true
+	}
+	if cfg.Token != "" {
+		scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		if strings.EqualFold(scheme, "Bearer") && subtle.ConstantTimeCompare([]byte(token), []byte(cfg.Token)) == 1 {
+			return "", true
+		}
+	}
+	if len(cfg.Secret) == 0 {
+		return "", false
+	}
+	q := r.URL.Query()
+	exp, sig := q.Get("exp"), q.Get("sig")
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return "", false
+	}
+	// the signed path may be the name or any dir above it
+	for scope := name; ; scope = path.Dir(scope) {
+		if hmac.Equal([]byte(sig), []byte(shareSign(cfg.Secret, scope, exp))) {
+			v := url.Values{}
+			v.Set("exp", exp)
+			v.Set("sig", sig)
+			return v.Encode(), true
+		}
+		if scope == "/" {
+			return "", false
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package drive
+
+import (
+	"encoding/json"
+	"html/template"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/file"
+)
+
+// README larger than it is not shown in listing
+const maxReadme = 64 << 10
+
+// shareEntry is an item of dir listing
+type shareEntry struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	IsDir   bool      `json:"isDir"`
+	URL     string    `json:"url"`
+}
+
+type shareIndex struct {
+	Path    string       `json:"path"`
+	Entries []shareEntry `json:"entries"`
+	Readme  string       `json:"readme,omitempty"`
+	// sort column and order of html page
+	Sort  string `json:"-"`
+	Desc  bool   `json:"-"`
+	Root  bool   `json:"-"`
+	Title string `json:"-"`
+	// signature of the link, kept in links of the page
+	Auth string `json:"-"`
+}
+
+// SortURL returns url sorting by column, the current column toggles its order
+func (i *shareIndex) SortURL(column string) string {
+	order := "asc"
+	if column == i.Sort && !i.Desc {
+		order = "desc"
+	}
+	return i.Query("sort=" + column + "&order=" + order)
+}
+
+// Query returns query of a link in the page, the signature is kept
+func (i *shareIndex) Query(query string) string {
+	if i.Auth != "" {
+		query = i.Auth + "&" + query
+	}
+	return "?" + strings.TrimSuffix(query, "&")
+}
+
+// Arrow marks the sorted column
+func (i *shareIndex) Arrow(column string) string {
+	if column != i.Sort {
+		return ""
+	}
+	if i.Desc {
+		return " ↓"
+	}
+	return " ↑"
+}
+
+// shareDir serves listing of dir as html, or json with format=json,
+// auth is the signature of the request kept in links
+func (f *FS) shareDir(cfg pkg.ShareConfig, w http.ResponseWriter, r *http.Request, name, auth string, dir pkg.File) {
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		// relative links of the page need the trailing slash
+		u := *r.URL
+		u.Path += "/"
+		http.Redirect(w, r, u.String(), http.StatusMovedPermanently)
+		return
+	}
+	files, err := f.list(dir)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	query := r.URL.Query()
+	index := &shareIndex{Path: name, Sort: query.Get("sort"), Desc: query.Get("order") == "desc", Root: name == "/", Auth: auth}
+	if index.Sort != "size" && index.Sort != "time" {
+		index.Sort = "name"
+	}
+	index.Title = "Index of " + name
+	var readme pkg.File
+	for _, v := range files {
+		entry := shareEntry{Name: v.Name(), Size: v.Size(), ModTime: v.ModTime(), IsDir: v.IsDir()}
+		entry.URL = (&url.URL{Path: r.URL.Path + v.Name()}).EscapedPath()
+		if entry.IsDir {
+			entry.URL += "/"
+		}
+		if auth != "" {
+			entry.URL += "?" + auth
+		}
+		if !entry.IsDir && cfg.Readme && isReadme(v.Name()) && (readme == nil || v.Name() < readme.Name()) {
+			readme = v
+		}
+		index.Entries = append(index.Entries, entry)
+	}
+	sortEntries(index.Entries, index.Sort, index.Desc)
+	if readme != nil && readme.Size() <= maxReadme {
+		index.Readme = f.readme(readme)
+	}
+	if query.Get("format") == "json" {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(index)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err = indexPage.Execute(w, index); err != nil {
+		writeError(w, err)
+	}
+}
+
+// sortEntries sorts dirs before files by column
+func sortEntries(entries []shareEntry, column string, desc bool) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.IsDir != b.IsDir {
+			return a.IsDir
+		}
+		if desc {
+			a, b = b, a
+		}
+		switch column {
+		case "size":
+			if a.Size != b.Size {
+				return a.Size < b.Size
+			}
+		case "time":
+			if !a.ModTime.Equal(b.ModTime) {
+				return a.ModTime.Before(b.ModTime)
+			}
+		}
+		return a.Name < b.Name
+	})
+}
+
+func isReadme(name string) bool {
+	switch strings.ToLower(name) {
+	case "readme.md", "readme.txt", "readme":
+		return true
+	}
+	return false
+}
+
+// readme returns content of the README, empty when it fails to download
+func (f *FS) readme(info pkg.File) string {
+	resp, err := f.api.Download(info, 0)
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return ""
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxReadme))
+	return string(data)
+}
+
+var indexPage = template.Must(template.New("index").Funcs(template.FuncMap{
+	"size": func(e shareEntry) string {
+		if e.IsDir {
+			return "-"
+		}
+		return file.ReadableSize(uint64(e.Size))
+	},
+	"time": func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.Format("2006-01-02 15:04:05")
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; min-width: 60%; }
+th, td { padding: 4px 12px; text-align: left; }
+td.size { text-align: right; }
+tr:nth-child(even) { background: #f5f5f5; }
+a { text-decoration: none; }
+pre { background: #f5f5f5; padding: 1em; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<table>
+<tr><th><a href="{{.SortURL "name"}}">Name{{.Arrow "name"}}</a></th><th><a href="{{.SortURL "size"}}">Size{{.Arrow "size"}}</a></th><th><a href="{{.SortURL "time"}}">Modified{{.Arrow "time"}}</a></th></tr>
+{{if not .Root}}<tr><td><a href="../{{if .Auth}}{{.Query ""}}{{end}}">../</a></td><td></td><td></td></tr>
+{{end}}{{range .Entries}}<tr><td><a href="{{.URL}}">{{.Name}}{{if .IsDir}}/{{end}}</a></td><td class="size">{{size .}}</td><td>{{time .ModTime}}</td></tr>
+{{end}}</table>
+{{if .Readme}}<pre>{{.Readme}}</pre>
+{{end}}</body>
+</html>
+`))
@@ -0,0 +1,38 @@
+package drive
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/file"
+)
+
+// proxyShare streams the file through the server, conns caps requests reading the cloud at the same time.
+// Range and If-Range are answered by ranged reads of File, the cloud url is shared by requests
+// of the file and renewed once it expires.
+func (f *FS) proxyShare(conns chan struct{}, target string, info pkg.File, w http.ResponseWriter, r *http.Request) {
+	if conns != nil {
+		select {
+		case conns <- struct{}{}:
+			defer func() { <-conns }()
+		case <-r.Context().Done():
+			return
+		}
+	}
+	content := &File{api: f.api, info: info}
+	if v, ok := f.share.Load(target); ok {
+		content.url, _ = url.Parse(v.(string))
+	}
+	defer func() {
+		content.Close()
+		if content.url != nil {
+			f.share.Store(target, content.url.String())
+		}
+	}()
+	header := w.Header()
+	header.Set("ETag", file.ETag(info))
+	// sniffing the type would read the head of file by an extra request
+	header.Set("Content-Type", file.ContentType(info.Name()))
+	http.ServeContent(w, r, info.Name(), info.ModTime(), content)
+}
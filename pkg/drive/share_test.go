@@ -0,0 +1,219 @@
+package drive
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gowsp/cloud189/pkg"
+)
+
+// shareApi serves content of files by their name
+type shareApi struct {
+	*memApi
+}
+
+func (m *shareApi) Download(f pkg.File, start int64) (*http.Response, error) {
+	req := httptest.NewRequest(http.MethodGet, "http://cdn.test/"+f.Id()+"?Expires=0", nil)
+	return &http.Response{StatusCode: 200, Request: req, Body: io.NopCloser(strings.NewReader("content of " + f.Name()))}, nil
+}
+
+func newShareFS() *FS {
+	f := newGlobFS()
+	api := f.api.(*memApi)
+	api.files[1].FileSize = 300
+	api.files[2].FileSize = 100
+	api.add("g11", "g7", "README.md", false)
+	return New(&shareApi{memApi: api}).(*FS)
+}
+
+func TestShareDir(t *testing.T) {
+	f := newShareFS()
+	handler, err := f.Share(pkg.ShareConfig{Prefix: "/s/", Readme: true}, "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	if w := get("/s/backup?sort=size"); w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/s/backup/?sort=size" {
+		t.Errorf("redirect: status %d, location %s", w.Code, w.Header().Get("Location"))
+	}
+
+	w := get("/s/backup/?format=json&sort=size&order=desc")
+	var index struct {
+		Path    string
+		Entries []shareEntry
+	}
+	if err = json.Unmarshal(w.Body.Bytes(), &index); err != nil {
+		t.Fatalf("json listing: %v, %s", err, w.Body)
+	}
+	var names []string
+	for _, e := range index.Entries {
+		names = append(names, e.Name)
+	}
+	if index.Path != "/backup" || strings.Join(names, ",") != "sub,2023-01.tar,2023-02.tar,2024-01.tar" {
+		t.Errorf("json listing: %s %v", index.Path, names)
+	}
+	if index.Entries[0].URL != "/s/backup/sub/" || index.Entries[1].URL != "/s/backup/2023-01.tar" {
+		t.Errorf("entry urls: %s %s", index.Entries[0].URL, index.Entries[1].URL)
+	}
+
+	w = get("/s/photos/")
+	body := w.Body.String()
+	if w.Code != http.StatusOK || !strings.Contains(w.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("html listing: status %d", w.Code)
+	}
+	for _, want := range []string{`href="/s/photos/%5Bx%5D.jpg"`, `href="../"`, `href="?sort=name&amp;order=desc"`, "<pre>content of README.md</pre>"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("html listing lacks %s: %s", want, body)
+		}
+	}
+	if strings.Contains(get("/s/").Body.String(), `href="../"`) {
+		t.Error("root listing has parent link")
+	}
+	if w = get("/s/photos/a.jpg"); w.Code != http.StatusFound || w.Header().Get("Location") != "http://cdn.test/g8?Expires=0" {
+		t.Errorf("file: status %d, location %s", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestShareAuth(t *testing.T) {
+	secret := []byte("secret")
+	handler, err := newShareFS().Share(pkg.ShareConfig{Prefix: "/", Token: "t0ken", Secret: secret}, "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(target, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+	if w := get("/photos/a.jpg", ""); w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("anonymous: status %d", w.Code)
+	}
+	if w := get("/photos/a.jpg", "wrong"); w.Code != http.StatusUnauthorized {
+		t.Errorf("wrong token: status %d", w.Code)
+	}
+	if w := get("/photos/a.jpg", "t0ken"); w.Code != http.StatusFound {
+		t.Errorf("bearer token: status %d", w.Code)
+	}
+
+	file := SignShare(secret, "/photos/a.jpg", time.Now().Add(time.Hour))
+	if w := get("/photos/a.jpg?"+file, ""); w.Code != http.StatusFound {
+		t.Errorf("signed file: status %d", w.Code)
+	}
+	if w := get("/photos/b.png?"+file, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("signature of other file: status %d", w.Code)
+	}
+	if w := get("/photos/a.jpg?"+SignShare([]byte("other"), "/photos/a.jpg", time.Now().Add(time.Hour)), ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("other secret: status %d", w.Code)
+	}
+	if w := get("/photos/a.jpg?"+SignShare(secret, "/photos/a.jpg", time.Now().Add(-time.Minute)), ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expired link: status %d", w.Code)
+	}
+
+	dir := SignShare(secret, "/backup", time.Now().Add(time.Hour))
+	if w := get("/backup/sub/deep.tar?"+dir, ""); w.Code != http.StatusFound {
+		t.Errorf("file below signed dir: status %d", w.Code)
+	}
+	if w := get("/photos/a.jpg?"+dir, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("file outside signed dir: status %d", w.Code)
+	}
+	body := get("/backup/?"+dir, "").Body.String()
+	if !strings.Contains(body, `href="/backup/sub/?`+strings.ReplaceAll(dir, "&", "&amp;")+`"`) ||
+		!strings.Contains(body, `href="?`+strings.ReplaceAll(dir, "&", "&amp;")+`&amp;sort=name&amp;order=desc"`) {
+		t.Errorf("links of signed listing lack signature: %s", body)
+	}
+}
+
+func TestShareProxy(t *testing.T) {
+	content := strings.Repeat("0123456789", 1000)
+	slow, release := make(chan struct{}), make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			close(slow)
+			<-release
+		}
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+
+	api := &rangeApi{url: server.URL}
+	api.add("f1", "-11", "movie.mp4", false)
+	api.add("slow", "-11", "slow.bin", false)
+	for _, f := range api.files {
+		f.FileSize = int64(len(content))
+		f.MD5 = "d41d8cd98f00b204e9800998ecf8427e"
+	}
+	f := New(api).(*FS)
+	handler, err := f.Share(pkg.ShareConfig{Prefix: "/", Proxy: true, ProxyConns: 1}, "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	do := func(method, target string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	w := do(http.MethodGet, "/movie.mp4", nil)
+	if w.Code != http.StatusOK || w.Body.String() != content || w.Header().Get("Content-Type") != "video/mp4" ||
+		w.Header().Get("Accept-Ranges") != "bytes" || w.Header().Get("Content-Length") != "10000" {
+		t.Errorf("full body: status %d, header %v", w.Code, w.Header())
+	}
+	etag := w.Header().Get("ETag")
+	w = do(http.MethodGet, "/movie.mp4", map[string]string{"Range": "bytes=5000-5004", "If-Range": etag})
+	if w.Code != http.StatusPartialContent || w.Body.String() != "01234" || w.Header().Get("Content-Range") != "bytes 5000-5004/10000" {
+		t.Errorf("range: status %d, body %q, header %v", w.Code, w.Body, w.Header())
+	}
+	w = do(http.MethodGet, "/movie.mp4", map[string]string{"Range": "bytes=5000-5004", "If-Range": `"other"`})
+	if w.Code != http.StatusOK || w.Body.Len() != len(content) {
+		t.Errorf("stale If-Range: status %d, length %d", w.Code, w.Body.Len())
+	}
+	if w = do(http.MethodGet, "/movie.mp4", map[string]string{"Range": "bytes=20000-"}); w.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Errorf("unsatisfiable range: status %d", w.Code)
+	}
+	if api.calls != 1 {
+		t.Errorf("cloud url resolved %d times", api.calls)
+	}
+	// expired url is resolved again
+	f.share.Store("/movie.mp4", server.URL+"/f1?Expires=1")
+	if w = do(http.MethodGet, "/movie.mp4", nil); w.Code != http.StatusOK || w.Body.String() != content || api.calls != 2 {
+		t.Errorf("expired url: status %d, resolved %d times", w.Code, api.calls)
+	}
+
+	// the only connection is held by the slow request
+	done := make(chan struct{})
+	go func() {
+		do(http.MethodGet, "/slow.bin", nil)
+		close(done)
+	}()
+	<-slow
+	queued := make(chan *httptest.ResponseRecorder)
+	go func() { queued <- do(http.MethodGet, "/movie.mp4", nil) }()
+	select {
+	case <-queued:
+		t.Error("connection limit exceeded")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-done
+	if w = <-queued; w.Code != http.StatusOK {
+		t.Errorf("queued request: status %d", w.Code)
+	}
+}
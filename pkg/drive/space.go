@@ -0,0 +1,53 @@
+package drive
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gowsp/cloud189/pkg"
+)
+
+// CopyTo copies source of this space into target dir of another space
+func (f *FS) CopyTo(cfg pkg.TaskConfig, space pkg.Drive, target string, source ...string) error {
+	other, ok := space.(*FS)
+	if !ok {
+		return errors.New("unsupported drive")
+	}
+	dest, err := other.stat(target)
+	if err != nil || !dest.IsDir() {
+		return fmt.Errorf("%s: file does not exist or not a directory", target)
+	}
+	src, err := f.resolve(source...)
+	if len(src) == 0 {
+		return err
+	}
+	src, e := other.prepare(cfg, dest, src)
+	if e != nil || len(src) == 0 {
+		return errors.Join(e, err)
+	}
+	defer other.load(dest.Id()).invalid()
+	// the task is polled by the family api which creates it
+	var id string
+	owner := f
+	from, to := familyOf(f.api), familyOf(other.api)
+	switch {
+	case from != nil && to == nil:
+		id, e = from.SaveToPerson(dest, src...)
+	case from == nil && to != nil:
+		id, e = to.SaveFromPerson(dest, src...)
+		owner = other
+	default:
+		e = errors.New("copy between personal and family cloud only")
+	}
+	if e == nil {
+		e = owner.wait(cfg, id, dest, src)
+	}
+	return errors.Join(e, err)
+}
+
+func familyOf(api pkg.DriveApi) pkg.FamilyApi {
+	if family, ok := api.(pkg.FamilyApi); ok && family.FamilyId() != "" {
+		return family
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package drive
+
+import (
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/gowsp/cloud189/pkg"
+)
+
+func (f *FS) Task(id string) (*pkg.TaskInfo, error) {
+	return f.api.CheckTask(id)
+}
+
+func (f *FS) copy(cfg pkg.TaskConfig, dest pkg.File, files ...pkg.File) error {
+	files, err := f.prepare(cfg, dest, files)
+	if err != nil || len(files) == 0 {
+		return err
+	}
+	id, err := f.api.Copy(dest, files...)
+	if err != nil {
+		return err
+	}
+	return f.wait(cfg, id, dest, files)
+}
+
+func (f *FS) move(cfg pkg.TaskConfig, dest pkg.File, files ...pkg.File) error {
+	files, err := f.prepare(cfg, dest, files)
+	if err != nil || len(files) == 0 {
+		return err
+	}
+	id, err := f.api.Move(dest, files...)
+	if err != nil {
+		return err
+	}
+	return f.wait(cfg, id, dest, files)
+}
+
+func (f *FS) remove(cfg pkg.TaskConfig, files ...pkg.File) error {
+	id, err := f.api.Delete(files...)
+	if err != nil {
+		return err
+	}
+	return f.wait(cfg, id, nil, files)
+}
+
+// prepare applies conflict policy to files going into dir of this fs before the task is submitted,
+// overwrite and rename are left to the server, existing items are kept if the task fails
+func (f *FS) prepare(cfg pkg.TaskConfig, dir pkg.File, files []pkg.File) ([]pkg.File, error) {
+	children, err := f.api.List(dir, pkg.ALL)
+	if err != nil {
+		return nil, err
+	}
+	exists := make(map[string]pkg.File, len(children))
+	for _, child := range children {
+		exists[child.Name()] = child
+	}
+	policy := cfg.Policy()
+	result := make([]pkg.File, 0, len(files))
+	for _, file := range files {
+		old, ok := exists[file.Name()]
+		if !ok || old.Id() == file.Id() {
+			result = append(result, file)
+			continue
+		}
+		switch policy {
+		case pkg.ConflictFail:
+			return nil, fmt.Errorf("%s: %w in target dir", file.Name(), fs.ErrExist)
+		case pkg.ConflictSkip:
+			continue
+		case pkg.ConflictOverwrite, pkg.ConflictRename:
+			if _, ok := f.api.(pkg.ConflictApi); !ok {
+				return nil, fmt.Errorf("%s: %w in target dir, %s is not supported", file.Name(), fs.ErrExist, policy)
+			}
+		}
+		result = append(result, file)
+	}
+	return result, nil
+}
+
+// first interval of polling a batch task, doubled up to 2s
+var taskPoll = 200 * time.Millisecond
+
+// wait polls the batch task until it finishes or times out,
+// dest is the target dir of copy or move, files are the submitted sources
+func (f *FS) wait(cfg pkg.TaskConfig, id string, dest pkg.File, files []pkg.File) error {
+	if id == "" {
+		return nil
+	}
+	info := &pkg.TaskInfo{Id: id, Status: pkg.TaskInit, Total: len(files)}
+	report := func() {
+		if cfg.Progress != nil {
+			cfg.Progress(*info)
+		}
+	}
+	report()
+	if cfg.Async {
+		return nil
+	}
+	deadline := time.Now().Add(cfg.WaitTimeout())
+	interval := taskPoll
+	for {
+		time.Sleep(interval)
+		status, err := f.api.CheckTask(id)
+		if err != nil {
+			return err
+		}
+		info = status
+		switch info.Status {
+		case pkg.TaskDone:
+			taskResult(info, files)
+			report()
+			if info.Failed > 0 {
+				return fmt.Errorf("task %s: %d of %d files failed", id, info.Failed, len(files))
+			}
+			return nil
+		case pkg.TaskConflict:
+			// items created after prepare, resolved by server
+			resolver, ok := f.api.(pkg.ConflictApi)
+			if ok && dest != nil && cfg.Policy() != pkg.ConflictFail {
+				if err = resolver.ResolveConflict(id, dest, cfg.Policy(), info.Conflicts...); err != nil {
+					return err
+				}
+				break
+			}
+			taskResult(info, files)
+			report()
+			return fmt.Errorf("task %s: %d files conflict with existing ones", id, len(info.Conflicts))
+		}
+		report()
+		if time.Now().After(deadline) {
+			return fmt.Errorf("task %s is still %s after %s", id, info.Status, cfg.WaitTimeout())
+		}
+		if interval < 2*time.Second {
+			interval *= 2
+		}
+	}
+}
+
+// taskResult fills result of each submitted file
+func taskResult(info *pkg.TaskInfo, files []pkg.File) {
+	done := make(map[string]bool, len(info.SucceededIds))
+	for _, id := range info.SucceededIds {
+		done[id] = true
+	}
+	conflict := make(map[string]bool, len(info.Conflicts))
+	for _, f := range info.Conflicts {
+		conflict[f.Id] = true
+	}
+	info.Files = make([]pkg.TaskFile, len(files))
+	for i, file := range files {
+		result := pkg.TaskFileFailed
+		switch {
+		case conflict[file.Id()]:
+			result = pkg.TaskFileConflict
+		case done[file.Id()]:
+			result = pkg.TaskFileDone
+		case info.Status == pkg.TaskDone && info.Failed == 0:
+			result = pkg.TaskFileDone
+		}
+		info.Files[i] = pkg.TaskFile{Id: file.Id(), Name: file.Name(), IsDir: file.IsDir(), Result: result}
+	}
+}
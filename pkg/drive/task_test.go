@@ -0,0 +1,261 @@
+package drive
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/file"
+)
+
+type taskApi struct {
+	*memApi
+	deleted []string
+}
+
+func (a *taskApi) Delete(files ...pkg.File) (string, error) {
+	for _, f := range files {
+		a.deleted = append(a.deleted, f.Id())
+	}
+	return "", nil
+}
+
+func TestPrepare(t *testing.T) {
+	mem := &memApi{}
+	mem.add("d1", "-11", "src", true)
+	mem.add("f1", "d1", "a.txt", false)
+	mem.add("f2", "d1", "b.txt", false)
+	mem.add("d2", "-11", "dst", true)
+	mem.add("f3", "d2", "a.txt", false)
+	api := &taskApi{memApi: mem}
+	f := New(api).(*FS)
+	dst, _ := f.stat("/dst")
+	files, _ := f.resolve("/src/a.txt", "/src/b.txt")
+
+	if _, err := f.prepare(pkg.TaskConfig{}, dst, files); !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("fail policy: %v", err)
+	}
+	result, err := f.prepare(pkg.TaskConfig{Conflict: pkg.ConflictSkip}, dst, files)
+	if err != nil || len(result) != 1 || result[0].Id() != "f2" {
+		t.Fatalf("skip policy: %v %v", result, err)
+	}
+	for _, policy := range []pkg.ConflictPolicy{pkg.ConflictRename, pkg.ConflictOverwrite} {
+		if _, err := f.prepare(pkg.TaskConfig{Conflict: policy}, dst, files); err == nil {
+			t.Fatalf("%s needs server support", policy)
+		}
+		resolver := New(&statusApi{memApi: mem}).(*FS)
+		result, err = resolver.prepare(pkg.TaskConfig{Conflict: policy}, dst, files)
+		if err != nil || len(result) != 2 {
+			t.Fatalf("%s policy: %v %v", policy, result, err)
+		}
+	}
+	if len(api.deleted) != 0 {
+		t.Fatalf("existing file recycled before the task, got %v", api.deleted)
+	}
+	// moving into the dir it is already in is no conflict
+	same, _ := f.resolve("/dst/a.txt")
+	if result, err = f.prepare(pkg.TaskConfig{}, dst, same); err != nil || len(result) != 1 {
+		t.Fatalf("same file: %v %v", result, err)
+	}
+}
+
+// statusApi answers CheckTask by statuses in order, the last one repeats
+type statusApi struct {
+	*memApi
+	statuses []pkg.TaskInfo
+	checks   int
+	resolved []pkg.TaskFile
+}
+
+func (a *statusApi) CheckTask(id string) (*pkg.TaskInfo, error) {
+	info := a.statuses[min(a.checks, len(a.statuses)-1)]
+	a.checks++
+	info.Id = id
+	return &info, nil
+}
+
+func (a *statusApi) ResolveConflict(id string, target pkg.File, policy pkg.ConflictPolicy, files ...pkg.TaskFile) error {
+	a.resolved = append(a.resolved, files...)
+	return nil
+}
+
+// copyApi copies by a task answered by statusApi and records deleted files
+type copyApi struct {
+	*statusApi
+	deleted []string
+}
+
+func (a *copyApi) Copy(target pkg.File, files ...pkg.File) (string, error) {
+	return "t1", nil
+}
+
+func (a *copyApi) Delete(files ...pkg.File) (string, error) {
+	for _, f := range files {
+		a.deleted = append(a.deleted, f.Id())
+	}
+	return "", nil
+}
+
+func TestOverwriteFailed(t *testing.T) {
+	old := taskPoll
+	taskPoll = time.Millisecond
+	t.Cleanup(func() { taskPoll = old })
+
+	mem := &memApi{}
+	mem.add("d1", "-11", "src", true)
+	mem.add("f1", "d1", "a.txt", false)
+	mem.add("d2", "-11", "dst", true)
+	mem.add("f3", "d2", "a.txt", false)
+	conflict := pkg.TaskInfo{Status: pkg.TaskConflict, Conflicts: []pkg.TaskFile{{Id: "f1", Name: "a.txt"}}}
+	api := &copyApi{statusApi: &statusApi{memApi: mem, statuses: []pkg.TaskInfo{conflict, {Status: pkg.TaskDone, Failed: 1}}}}
+	f := New(api).(*FS)
+
+	if err := f.Copy(pkg.TaskConfig{Conflict: pkg.ConflictOverwrite}, "/dst", "/src/a.txt"); err == nil {
+		t.Fatal("failed task should fail the copy")
+	}
+	if len(api.resolved) != 1 || api.resolved[0].Id != "f1" {
+		t.Errorf("conflict should be resolved by server, got %v", api.resolved)
+	}
+	if len(api.deleted) != 0 {
+		t.Errorf("existing file recycled, got %v", api.deleted)
+	}
+	if existing, err := f.stat("/dst/a.txt"); err != nil || existing.Id() != "f3" {
+		t.Errorf("existing file lost: %v %v", existing, err)
+	}
+}
+
+func TestWait(t *testing.T) {
+	old := taskPoll
+	taskPoll = time.Millisecond
+	t.Cleanup(func() { taskPoll = old })
+
+	mem := &memApi{}
+	mem.add("d1", "-11", "dst", true)
+	mem.add("f1", "-11", "a.txt", false)
+	mem.add("f2", "-11", "b.txt", false)
+	running := pkg.TaskInfo{Status: pkg.TaskRunning}
+	conflict := pkg.TaskInfo{Status: pkg.TaskConflict, Conflicts: []pkg.TaskFile{{Id: "f2", Name: "b.txt"}}}
+	cases := []struct {
+		name     string
+		cfg      pkg.TaskConfig
+		statuses []pkg.TaskInfo
+		err      string
+		results  []string
+		checks   int
+	}{
+		{"success", pkg.TaskConfig{}, []pkg.TaskInfo{running, {Status: pkg.TaskDone, Succeeded: 2}},
+			"", []string{pkg.TaskFileDone, pkg.TaskFileDone}, 2},
+		{"failed file", pkg.TaskConfig{}, []pkg.TaskInfo{running, {Status: pkg.TaskDone, Failed: 1, SucceededIds: []string{"f1"}}},
+			"1 of 2 files failed", []string{pkg.TaskFileDone, pkg.TaskFileFailed}, 2},
+		{"conflict", pkg.TaskConfig{}, []pkg.TaskInfo{running, conflict},
+			"1 files conflict", []string{pkg.TaskFileFailed, pkg.TaskFileConflict}, 2},
+		{"resolved conflict", pkg.TaskConfig{Conflict: pkg.ConflictOverwrite}, []pkg.TaskInfo{running, conflict, {Status: pkg.TaskDone}},
+			"", []string{pkg.TaskFileDone, pkg.TaskFileDone}, 3},
+		{"timeout", pkg.TaskConfig{Timeout: 20 * time.Millisecond}, []pkg.TaskInfo{running},
+			"still running", nil, -1},
+		{"async", pkg.TaskConfig{Async: true}, []pkg.TaskInfo{running},
+			"", nil, 0},
+	}
+	for _, tc := range cases {
+		api := &statusApi{memApi: mem, statuses: tc.statuses}
+		f := New(api).(*FS)
+		dst, _ := f.stat("/dst")
+		files, _ := f.resolve("/a.txt", "/b.txt")
+		var reports []pkg.TaskInfo
+		tc.cfg.Progress = func(info pkg.TaskInfo) { reports = append(reports, info) }
+
+		err := f.wait(tc.cfg, "t1", dst, files)
+		if tc.err == "" && err != nil || tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+			t.Errorf("%s: error %v, want %q", tc.name, err, tc.err)
+		}
+		if tc.checks >= 0 && api.checks != tc.checks {
+			t.Errorf("%s: checked %d times, want %d", tc.name, api.checks, tc.checks)
+		}
+		if len(reports) == 0 || reports[0].Status != pkg.TaskInit {
+			t.Errorf("%s: submission not reported %v", tc.name, reports)
+			continue
+		}
+		if tc.results == nil {
+			continue
+		}
+		last := reports[len(reports)-1]
+		var results []string
+		for _, file := range last.Files {
+			results = append(results, file.Result)
+		}
+		if strings.Join(results, ",") != strings.Join(tc.results, ",") {
+			t.Errorf("%s: file results %v, want %v", tc.name, results, tc.results)
+		}
+		if tc.name == "resolved conflict" && (len(api.resolved) != 1 || api.resolved[0].Id != "f2") {
+			t.Errorf("%s: resolved %v", tc.name, api.resolved)
+		}
+	}
+}
+
+// moveApi moves, renames and deletes files of memApi
+type moveApi struct {
+	*memApi
+	deleted []string
+}
+
+func (a *moveApi) find(id string) *file.FileInfo {
+	for _, f := range a.files {
+		if f.Id() == id {
+			return f
+		}
+	}
+	return nil
+}
+
+func (a *moveApi) Rename(target pkg.File, name string) error {
+	a.find(target.Id()).FileName = name
+	return nil
+}
+
+func (a *moveApi) Move(target pkg.File, files ...pkg.File) (string, error) {
+	for _, f := range files {
+		a.find(f.Id()).ParentId = json.Number(target.Id())
+	}
+	return "", nil
+}
+
+func (a *moveApi) Delete(files ...pkg.File) (string, error) {
+	for _, f := range files {
+		a.deleted = append(a.deleted, f.Id())
+		a.find(f.Id()).ParentId = "recycle"
+	}
+	return "", nil
+}
+
+func TestMoveRename(t *testing.T) {
+	for _, policy := range []pkg.ConflictPolicy{pkg.ConflictFail, pkg.ConflictOverwrite} {
+		mem := &memApi{}
+		mem.add("a", "-11", "a", true)
+		mem.add("b", "-11", "b", true)
+		mem.add("f1", "a", "x.txt", false)
+		// same name as the source in target dir, not the destination
+		mem.add("f2", "b", "x.txt", false)
+		if policy == pkg.ConflictOverwrite {
+			mem.add("f3", "b", "y.txt", false)
+		}
+		api := &moveApi{memApi: mem}
+		f := New(api).(*FS)
+		if err := f.Move(pkg.TaskConfig{Conflict: policy}, "/b/y.txt", "/a/x.txt"); err != nil {
+			t.Fatalf("%s: %v", policy, err)
+		}
+		moved, other := api.find("f1"), api.find("f2")
+		if moved.PId() != "b" || moved.Name() != "y.txt" {
+			t.Errorf("%s: source at %s/%s", policy, moved.PId(), moved.Name())
+		}
+		if other.PId() != "b" || other.Name() != "x.txt" {
+			t.Errorf("%s: unrelated file at %s/%s", policy, other.PId(), other.Name())
+		}
+		if want := map[pkg.ConflictPolicy]string{pkg.ConflictOverwrite: "f3"}[policy]; strings.Join(api.deleted, ",") != want {
+			t.Errorf("%s: deleted %v, want %s", policy, api.deleted, want)
+		}
+	}
+}
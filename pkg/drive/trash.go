@@ -0,0 +1,64 @@
+package drive
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/gowsp/cloud189/pkg"
+)
+
+func (f *FS) Trash() ([]pkg.File, error) {
+	return f.api.ListRecycle()
+}
+
+// trash finds recycled files by id or name pattern
+func (f *FS) trash(names ...string) ([]pkg.File, error) {
+	recycled, err := f.api.ListRecycle()
+	if err != nil {
+		return nil, err
+	}
+	var files []pkg.File
+	var errs []error
+	for _, name := range names {
+		found := false
+		for _, file := range recycled {
+			ok := file.Id() == name || file.Name() == name
+			if !ok {
+				ok, err = path.Match(name, file.Name())
+				if err != nil {
+					return nil, err
+				}
+			}
+			if ok {
+				found = true
+				files = append(files, file)
+			}
+		}
+		if !found {
+			errs = append(errs, fmt.Errorf("%s: not found in recycle bin", name))
+		}
+	}
+	return files, errors.Join(errs...)
+}
+
+func (f *FS) Restore(name ...string) error {
+	files, err := f.trash(name...)
+	if len(files) == 0 {
+		return err
+	}
+	defer f.invalid(files...)
+	return errors.Join(f.api.Restore(files...), err)
+}
+
+func (f *FS) Purge(name ...string) error {
+	files, err := f.trash(name...)
+	if len(files) == 0 {
+		return err
+	}
+	return errors.Join(f.api.DeleteRecycle(files...), err)
+}
+
+func (f *FS) EmptyTrash() error {
+	return f.api.EmptyRecycle()
+}
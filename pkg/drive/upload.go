@@ -10,9 +10,13 @@ import (
 	"github.com/gowsp/cloud189/pkg/file"
 )
 
-func (client *FS) UploadFrom(file pkg.Upload) error {
+func (client *FS) UploadFrom(cfg pkg.UploadConfig, file pkg.Upload) error {
+	file, err := client.uploadPolicy(cfg.Conflict).apply(file)
+	if file == nil {
+		return err
+	}
 	uploader := client.api.Uploader()
-	return uploader.Write(file)
+	return uploader.Write(pkg.WithProgress(file, cfg.Progress))
 }
 func (client *FS) Upload(cfg pkg.UploadConfig, cloud string, locals ...string) error {
 	err := cfg.Check()
@@ -43,11 +47,19 @@ func (client *FS) Upload(cfg pkg.UploadConfig, cloud string, locals ...string) e
 		up = append(up, files...)
 	}
 	task := cfg.NewTask()
+	policy := client.uploadPolicy(cfg.Conflict)
 	uploader := client.api.Uploader()
 	for _, v := range up {
-		r := v
+		r, err := policy.apply(v)
+		if r == nil {
+			if err != nil {
+				log.Println(err)
+			}
+			continue
+		}
+		r = pkg.WithProgress(r, cfg.Progress)
 		task.Run(func() {
-			if err = uploader.Write(r); err != nil {
+			if err := uploader.Write(r); err != nil {
 				log.Println(err)
 			}
 		})
@@ -81,7 +93,7 @@ func (client *FS) uploadLocal(parent pkg.File, local string, parten string) ([]p
 			continue
 		}
 		if info.IsDir() {
-			filepath.WalkDir(localFile, func(path string, d fs.DirEntry, err error) error {
+			err = filepath.WalkDir(localFile, func(path string, d fs.DirEntry, err error) error {
 				if err != nil {
 					return err
 				}
@@ -90,7 +102,10 @@ func (client *FS) uploadLocal(parent pkg.File, local string, parten string) ([]p
 					if rel == "." {
 						return nil
 					}
-					f, _ := client.api.Mkdir(parent, rel)
+					f, err := client.api.Mkdir(parent, rel)
+					if err != nil {
+						return err
+					}
 					dirs[rel] = f.Id()
 					return nil
 				}
@@ -99,6 +114,9 @@ func (client *FS) uploadLocal(parent pkg.File, local string, parten string) ([]p
 				up = append(up, file.NewLocalFile(dirs[rel], path))
 				return err
 			})
+			if err != nil {
+				return nil, err
+			}
 		} else {
 			up = append(up, file.NewLocalFile(parent.Id(), localFile))
 		}
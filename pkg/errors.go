@@ -0,0 +1,153 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+)
+
+// kinds of api errors, match them by errors.Is
+var (
+	ErrSessionExpired = errors.New("session expired, please login again")
+	ErrQuotaExceeded  = errors.New("cloud space is not enough")
+	ErrDailyFlowLimit = errors.New("daily upload flow is over limit")
+	ErrFileExists     = errors.New("file already exists")
+	ErrNotFound       = errors.New("file not found")
+	ErrRateLimited    = errors.New("too many requests")
+)
+
+// error codes of response body, such as res_code of app api or code of upload api
+var errorCodes = map[string]error{
+	"InvalidSessionKey":        ErrSessionExpired,
+	"InvalidSignature":         ErrSessionExpired,
+	"InvalidAccessToken":       ErrSessionExpired,
+	"UserInvalidOpenToken":     ErrSessionExpired,
+	"UserNotLogin":             ErrSessionExpired,
+	"InsufficientStorageSpace": ErrQuotaExceeded,
+	"UploadSpaceOverLimit":     ErrQuotaExceeded,
+	"UserDayFlowOverLimited":   ErrDailyFlowLimit,
+	"FileAlreadyExists":        ErrFileExists,
+	"FolderAlreadyExists":      ErrFileExists,
+	"FileNotFound":             ErrNotFound,
+	"FolderNotFound":           ErrNotFound,
+	"ShareNotFound":            ErrNotFound,
+	"ShareInfoNotFound":        ErrNotFound,
+	"ServerBusy":               ErrRateLimited,
+	"TooManyRequests":          ErrRateLimited,
+	"FrequentOperation":        ErrRateLimited,
+}
+
+// Error is a failed api call
+type Error struct {
+	// api of the call, such as /createFolder.action
+	Op      string
+	Code    string
+	Message string
+	// http status, zero if the response is successful but carries an error code
+	Status int
+	// underlying error, such as the one with retry class
+	Err error
+}
+
+// NewError returns error of api op responding code and message
+func NewError(op, code, message string) *Error {
+	return &Error{Op: op, Code: code, Message: message}
+}
+
+// Result is the status part of api response body, res_code is 0 on success,
+// on failure it is a number or the error code itself, which may also be given by errorCode
+type Result struct {
+	ResCode    any    `json:"res_code"`
+	ResMessage string `json:"res_message"`
+	ErrorCode  string `json:"errorCode"`
+}
+
+// Err returns error of api op when the response fails
+func (r *Result) Err(op string) error {
+	code := fmt.Sprint(r.ResCode)
+	if r.ResCode == nil || code == "0" {
+		return nil
+	}
+	if r.ErrorCode != "" {
+		code = r.ErrorCode
+	}
+	return NewError(op, code, r.ResMessage)
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" && e.Err != nil {
+		msg = e.Err.Error()
+	}
+	if msg == "" {
+		if kind := e.Kind(); kind != nil {
+			msg = kind.Error()
+		} else if e.Status != 0 {
+			msg = http.StatusText(e.Status)
+		}
+	}
+	code := e.Code
+	if code == "" && e.Status != 0 {
+		code = fmt.Sprintf("status %d", e.Status)
+	}
+	switch {
+	case code != "" && msg != "" && msg != code:
+		return fmt.Sprintf("%s: %s: %s", e.Op, code, msg)
+	case code != "":
+		return fmt.Sprintf("%s: %s", e.Op, code)
+	}
+	return fmt.Sprintf("%s: %s", e.Op, msg)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Kind returns sentinel error of the code or status, nil if unknown
+func (e *Error) Kind() error {
+	if kind, ok := errorCodes[e.Code]; ok {
+		return kind
+	}
+	switch e.Status {
+	case http.StatusUnauthorized:
+		return ErrSessionExpired
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	}
+	return nil
+}
+
+// Is matches the sentinel of e, ErrNotFound and ErrFileExists also match the ones of io/fs
+func (e *Error) Is(target error) bool {
+	kind := e.Kind()
+	if kind == nil {
+		return false
+	}
+	switch target {
+	case kind:
+		return true
+	case fs.ErrNotExist:
+		return kind == ErrNotFound
+	case fs.ErrExist:
+		return kind == ErrFileExists
+	}
+	return false
+}
+
+// StatusCode returns http status of serving err, fallback for unknown errors
+func StatusCode(err error, fallback int) int {
+	switch {
+	case errors.Is(err, ErrNotFound), errors.Is(err, fs.ErrNotExist):
+		return http.StatusNotFound
+	case errors.Is(err, ErrFileExists), errors.Is(err, fs.ErrExist):
+		return http.StatusConflict
+	case errors.Is(err, ErrQuotaExceeded), errors.Is(err, ErrDailyFlowLimit):
+		return http.StatusInsufficientStorage
+	case errors.Is(err, ErrRateLimited):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, ErrSessionExpired):
+		return http.StatusBadGateway
+	}
+	return fallback
+}
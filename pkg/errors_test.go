@@ -0,0 +1,47 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	tests := []struct {
+		err    *Error
+		kind   error
+		status int
+	}{
+		{NewError("/createFolder.action", "FileAlreadyExists", "文件已存在"), ErrFileExists, http.StatusConflict},
+		{NewError("/getFileDownloadUrl.action", "FileNotFound", ""), ErrNotFound, http.StatusNotFound},
+		{NewError("/person/commitMultiUploadFile", "UserDayFlowOverLimited", ""), ErrDailyFlowLimit, http.StatusInsufficientStorage},
+		{NewError("/createBatchTask.action", "InsufficientStorageSpace", ""), ErrQuotaExceeded, http.StatusInsufficientStorage},
+		{&Error{Op: "/listFiles.action", Status: http.StatusUnauthorized}, ErrSessionExpired, http.StatusBadGateway},
+		{&Error{Op: "/listFiles.action", Code: "ServerBusy", Status: http.StatusOK}, ErrRateLimited, http.StatusServiceUnavailable},
+		{NewError("/listShares.action", "Unknown", "unknown"), nil, http.StatusInternalServerError},
+	}
+	for _, test := range tests {
+		// wrapped by callers
+		err := fmt.Errorf("wrap: %w", test.err)
+		if test.err.Kind() != test.kind {
+			t.Errorf("%v: kind %v, want %v", test.err, test.err.Kind(), test.kind)
+		}
+		if test.kind != nil && !errors.Is(err, test.kind) {
+			t.Errorf("%v is not %v", err, test.kind)
+		}
+		if status := StatusCode(err, http.StatusInternalServerError); status != test.status {
+			t.Errorf("%v: status %d, want %d", err, status, test.status)
+		}
+	}
+	if !errors.Is(NewError("", "FileNotFound", ""), fs.ErrNotExist) {
+		t.Error("ErrNotFound does not match fs.ErrNotExist")
+	}
+	if !errors.Is(NewError("", "FileAlreadyExists", ""), fs.ErrExist) {
+		t.Error("ErrFileExists does not match fs.ErrExist")
+	}
+	if msg := NewError("/createFolder.action", "FileAlreadyExists", "文件已存在").Error(); msg != "/createFolder.action: FileAlreadyExists: 文件已存在" {
+		t.Errorf("message %s", msg)
+	}
+}
@@ -5,11 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/fs"
+	"mime"
 	"os"
 	"path"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/gowsp/cloud189/pkg"
 )
 
 const (
@@ -56,6 +59,10 @@ type ModTime time.Time
 
 func (j *ModTime) UnmarshalJSON(b []byte) error {
 	json := string(b)
+	if ms, err := strconv.ParseInt(json, 10, 64); err == nil {
+		*j = ModTime(time.UnixMilli(ms))
+		return nil
+	}
 	s := strings.Trim(json, "\"")
 	t, err := time.Parse("2006-01-02 15:04:05", s)
 	if err != nil {
@@ -85,10 +92,27 @@ func (f *FileInfo) ModTime() time.Time {
 }
 func (f *FileInfo) IsDir() bool      { return f.IsFolder }
 func (f *FileInfo) Sys() interface{} { return nil }
+func (f *FileInfo) FileMD5() string  { return f.MD5 }
 func (f *FileInfo) ContentType(ctx context.Context) (string, error) {
-	return path.Ext(f.Name()), nil
+	return ContentType(f.Name()), nil
 }
 func (f *FileInfo) ETag(ctx context.Context) (string, error) {
-	return strconv.FormatInt(f.ModTime().Unix(), 10), nil
+	return ETag(f), nil
 }
 func (f *FileInfo) Info() (fs.FileInfo, error) { return f, nil }
+
+// ContentType returns mime type by extension of name
+func ContentType(name string) string {
+	if t := mime.TypeByExtension(path.Ext(name)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// ETag returns strong etag of file content md5, or the one made of modification time and size
+func ETag(info fs.FileInfo) string {
+	if h, ok := info.(pkg.HashFile); ok && h.FileMD5() != "" && !info.IsDir() {
+		return `"` + strings.ToLower(h.FileMD5()) + `"`
+	}
+	return fmt.Sprintf(`"%x%x"`, info.ModTime().UnixNano(), info.Size())
+}
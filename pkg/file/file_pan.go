@@ -30,6 +30,9 @@ func IsSystemDir(file pkg.File) bool {
 }
 
 var Root = &sysFolder{FileId: "-11", FileName: "全部文件"}
+
+// FamilyRoot is the root of family cloud, listed by empty folder id
+var FamilyRoot = &sysFolder{FileName: "家庭云"}
 var system map[string]string = map[string]string{
 	"同步盘":  "0",
 	"私密空间": "-10",
@@ -0,0 +1,79 @@
+package file
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gowsp/cloud189/pkg"
+)
+
+// ShareResp is response of creating share link by app and web api
+type ShareResp struct {
+	pkg.Result
+	ShareId json.Number `json:"shareId"`
+	Links   []struct {
+		ShareId    json.Number `json:"shareId"`
+		Url        string      `json:"url"`
+		AccessUrl  string      `json:"accessUrl"`
+		AccessCode string      `json:"accessCode"`
+	} `json:"shareLinkList"`
+}
+
+// Link returns the link created for file, expire is days it lasts, 0 for never
+func (r *ShareResp) Link(file pkg.File, expire int) (*pkg.ShareLink, error) {
+	if len(r.Links) == 0 {
+		return nil, errors.New("error create share link")
+	}
+	now := time.Now()
+	link := r.Links[0]
+	share := &pkg.ShareLink{
+		Id:         r.ShareId.String(),
+		FileId:     file.Id(),
+		Name:       file.Name(),
+		IsDir:      file.IsDir(),
+		Url:        link.Url,
+		AccessCode: link.AccessCode,
+		CreateTime: now,
+	}
+	if share.Id == "" {
+		share.Id = link.ShareId.String()
+	}
+	if link.AccessUrl != "" {
+		share.Url = link.AccessUrl
+	}
+	if expire > 0 {
+		share.ExpireTime = now.AddDate(0, 0, expire)
+	}
+	return share, nil
+}
+
+// ShareItem is a share link listed by app and web api
+type ShareItem struct {
+	ShareId    json.Number `json:"shareId"`
+	FileId     json.Number `json:"fileId"`
+	FileName   string      `json:"fileName"`
+	IsFolder   bool        `json:"isFolder"`
+	AccessURL  string      `json:"accessURL"`
+	AccessCode string      `json:"accessCode"`
+	ShareTime  ModTime     `json:"shareTime"`
+	ExpireTime ModTime     `json:"expireTime"`
+}
+
+func (s *ShareItem) Link() pkg.ShareLink {
+	link := pkg.ShareLink{
+		Id:         s.ShareId.String(),
+		FileId:     s.FileId.String(),
+		Name:       s.FileName,
+		IsDir:      s.IsFolder,
+		Url:        s.AccessURL,
+		AccessCode: s.AccessCode,
+		CreateTime: time.Time(s.ShareTime),
+		ExpireTime: time.Time(s.ExpireTime),
+	}
+	// never expiring links are responded as year 2099
+	if link.ExpireTime.Year() >= 2099 || link.ExpireTime.Before(link.CreateTime) {
+		link.ExpireTime = time.Time{}
+	}
+	return link
+}
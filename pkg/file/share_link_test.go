@@ -0,0 +1,27 @@
+package file
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestShareLink(t *testing.T) {
+	var resp ShareResp
+	body := `{"res_code":0,"shareLinkList":[{"shareId":12,"url":"https://cloud.189.cn/t/a","accessCode":"x1"}]}`
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatal(err)
+	}
+	link, err := resp.Link(&FileInfo{FileId: "3", FileName: "a.txt"}, 0)
+	if err != nil || link.Id != "12" || link.FileId != "3" || link.AccessCode != "x1" || !link.ExpireTime.IsZero() {
+		t.Errorf("created link %+v, %v", link, err)
+	}
+
+	var item ShareItem
+	body = `{"shareId":12,"fileId":3,"fileName":"a.txt","shareTime":"2024-01-02 03:04:05","expireTime":4102416000000}`
+	if err := json.Unmarshal([]byte(body), &item); err != nil {
+		t.Fatal(err)
+	}
+	if l := item.Link(); l.Id != "12" || l.CreateTime.Year() != 2024 || !l.ExpireTime.IsZero() {
+		t.Errorf("listed link %+v", l)
+	}
+}
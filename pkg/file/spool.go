@@ -0,0 +1,144 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var ErrSpoolFull = errors.New("spool: temp space is full")
+
+// prefix of temp file names, leftovers of a killed process are found by it
+const spoolPrefix = "cloud189-spool-"
+
+// Spool keeps streamed uploads in local temp files, so that their md5 is known before upload
+// and the cloud can skip data it already has
+type Spool struct {
+	// dir of temp files, system temp dir when empty
+	Dir string
+	// max bytes of all temp files, 0 is unlimited
+	Limit int64
+
+	mu   sync.Mutex
+	used int64
+}
+
+func (s *Spool) reserve(n int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Limit > 0 && s.used+n > s.Limit {
+		return ErrSpoolFull
+	}
+	s.used += n
+	return nil
+}
+
+func (s *Spool) release(n int64) {
+	s.mu.Lock()
+	s.used -= n
+	s.mu.Unlock()
+}
+
+// Used returns bytes of temp files in use
+func (s *Spool) Used() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.used
+}
+
+// Clean removes temp files left by a previous process in Dir, the shared system temp dir is left as is
+func (s *Spool) Clean() error {
+	if s.Dir == "" {
+		return nil
+	}
+	files, err := filepath.Glob(filepath.Join(s.Dir, spoolPrefix+"*"))
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err = os.Remove(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Create copies data into a temp file and computes md5 of the file and its slices on the way,
+// size is the expected size or -1 when unknown such as chunked request.
+// The temp file is removed by Close of the returned file.
+func (s *Spool) Create(parentId, name string, data io.Reader, size int64) (*LocalFile, error) {
+	if size > 0 {
+		if err := s.reserve(size); err != nil {
+			return nil, err
+		}
+	}
+	reserved := max(size, 0)
+	tmp, err := os.CreateTemp(s.Dir, spoolPrefix+"*")
+	if err != nil {
+		s.release(reserved)
+		return nil, err
+	}
+	f := &LocalFile{parentId: parentId, name: name, file: tmp}
+	f.cleanup = func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		s.release(reserved)
+	}
+
+	if size >= 0 {
+		// a longer body fails instead of exceeding the reservation
+		data = io.LimitReader(data, size+1)
+	}
+	var out io.Writer = tmp
+	if size < 0 {
+		// unknown size is reserved as data arrives
+		out = &spoolWriter{spool: s, w: tmp, reserved: &reserved}
+	}
+	h := newSliceHash()
+	writer := io.MultiWriter(out, h)
+	var written int64
+	for {
+		var n int64
+		n, err = io.CopyN(writer, data, Slice)
+		written += n
+		if n > 0 {
+			h.endSlice()
+		}
+		if err != nil {
+			break
+		}
+	}
+	if err == io.EOF {
+		err = nil
+	}
+	if err == nil && size >= 0 && written != size {
+		err = fmt.Errorf("spool %s: received %d bytes of %d", name, written, size)
+	}
+	if err == nil {
+		f.info, err = tmp.Stat()
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	h.sum(f)
+	return f, nil
+}
+
+// spoolWriter reserves space of spool before writing
+type spoolWriter struct {
+	spool    *Spool
+	w        io.Writer
+	reserved *int64
+}
+
+func (w *spoolWriter) Write(p []byte) (int, error) {
+	if err := w.spool.reserve(int64(len(p))); err != nil {
+		return 0, err
+	}
+	*w.reserved += int64(len(p))
+	return w.w.Write(p)
+}
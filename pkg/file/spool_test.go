@@ -0,0 +1,63 @@
+package file
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSpool(t *testing.T) {
+	dir := t.TempDir()
+	data := bytes.Repeat([]byte("0123456789"), int(Slice*25/100))
+	local := filepath.Join(dir, "local")
+	if err := os.WriteFile(local, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	want := NewLocalFile("1", local).(*LocalFile)
+	defer want.Close()
+
+	s := &Spool{Dir: dir}
+	for _, size := range []int64{int64(len(data)), -1} {
+		f, err := s.Create("1", "a.bin", bytes.NewReader(data), size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Name() != "a.bin" || f.Size() != want.Size() || f.SliceNum() != 3 || f.LazyCheck() {
+			t.Errorf("size %d: name %s, size %d, slices %d", size, f.Name(), f.Size(), f.SliceNum())
+		}
+		if f.FileMD5() != want.FileMD5() || f.SliceMD5() != want.SliceMD5() {
+			t.Errorf("size %d: md5 %s %s, want %s %s", size, f.FileMD5(), f.SliceMD5(), want.FileMD5(), want.SliceMD5())
+		}
+		part, _ := io.ReadAll(f.Part(2).Data())
+		if !bytes.Equal(part, data[2*Slice:]) || f.Part(2).Name() != want.Part(2).Name() {
+			t.Errorf("size %d: last part of %d bytes", size, len(part))
+		}
+		if s.Used() != int64(len(data)) {
+			t.Errorf("size %d: used %d", size, s.Used())
+		}
+		f.Close()
+		if s.Used() != 0 {
+			t.Errorf("size %d: used %d after close", size, s.Used())
+		}
+	}
+
+	s.Limit = Slice
+	if _, err := s.Create("1", "a.bin", bytes.NewReader(data), int64(len(data))); !errors.Is(err, ErrSpoolFull) {
+		t.Errorf("known size over limit: %v", err)
+	}
+	if _, err := s.Create("1", "a.bin", bytes.NewReader(data), -1); !errors.Is(err, ErrSpoolFull) {
+		t.Errorf("chunked over limit: %v", err)
+	}
+	if _, err := s.Create("1", "a.bin", bytes.NewReader(data[:10]), 20); err == nil {
+		t.Error("short body is accepted")
+	}
+	if s.Used() != 0 {
+		t.Errorf("used %d after failures", s.Used())
+	}
+	if left, _ := filepath.Glob(filepath.Join(dir, spoolPrefix+"*")); len(left) != 0 {
+		t.Errorf("temp files left %v", left)
+	}
+}
@@ -1,19 +1,24 @@
 package file
 
 import (
+	"bytes"
 	"crypto/md5"
 	"encoding/base64"
 	"encoding/hex"
+	"hash"
 	"io"
 	"math"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/gowsp/cloud189/pkg"
 )
 
 type LocalFile struct {
-	parentId  string
+	parentId string
+	// name in cloud, name of local file when empty
+	name      string
 	file      *os.File
 	info      os.FileInfo
 	partName  []string
@@ -21,6 +26,10 @@ type LocalFile struct {
 	sliceMD5  string
 	sliceNum  int
 	overwrite bool
+	// modification time of source, zero when unknown such as spooled request bodies
+	modTime time.Time
+	// removes spooled temp file
+	cleanup func()
 }
 
 func NewLocalFile(parentId string, path string) pkg.Upload {
@@ -37,16 +46,22 @@ func NewLocalFile(parentId string, path string) pkg.Upload {
 	return &LocalFile{
 		parentId: parentId,
 		info:     info,
+		modTime:  info.ModTime(),
 		file:     source,
 		sliceNum: sliceNum,
 		partName: make([]string, sliceNum),
 	}
 }
 func (f *LocalFile) Close() {
+	if f.cleanup != nil {
+		f.cleanup()
+		return
+	}
 	f.file.Close()
 }
 func (f *LocalFile) Part(num int64) pkg.UploadPart {
-	data := io.NewSectionReader(f.file, int64(num*Slice), Slice)
+	offset := num * Slice
+	data := io.NewSectionReader(f.file, offset, min(Slice, f.Size()-offset))
 	return &FilePart{data: data, num: num, name: f.partName[num]}
 }
 
@@ -57,11 +72,17 @@ func (f *LocalFile) ParentId() string {
 	return f.parentId
 }
 func (f *LocalFile) Name() string {
+	if f.name != "" {
+		return f.name
+	}
 	return f.info.Name()
 }
 func (f *LocalFile) Size() int64 {
 	return f.info.Size()
 }
+func (f *LocalFile) ModTime() time.Time {
+	return f.modTime
+}
 func (f *LocalFile) SliceNum() int {
 	return f.sliceNum
 }
@@ -82,34 +103,74 @@ func (f *LocalFile) SliceMD5() string {
 }
 
 func (f *LocalFile) md5() error {
-	count := f.sliceNum
-	slices := make([]string, count)
-
-	global := md5.New()
-	detail := md5.New()
-	writer := io.MultiWriter(global, detail)
-
+	h := newSliceHash()
 	buf := make([]byte, 32*1024)
-	for i := 0; i < count; i++ {
+	for i := 0; i < f.sliceNum; i++ {
 		offset := int64(i * int(Slice))
 		s := io.NewSectionReader(f.file, offset, Slice)
-		io.CopyBuffer(writer, s, buf)
-		v := detail.Sum(nil)
-		slices[i] = strings.ToUpper(hex.EncodeToString(v))
-		f.partName[i] = base64.StdEncoding.EncodeToString(v)
-		detail.Reset()
-	}
-	f.fileMD5 = hex.EncodeToString(global.Sum(nil))
-	if count > 1 {
-		slice := strings.Join(slices, "\n")
-		detail.Write([]byte(slice))
-		f.sliceMD5 = hex.EncodeToString(detail.Sum(nil))
-	} else {
-		f.sliceMD5 = f.fileMD5
+		io.CopyBuffer(h, s, buf)
+		h.endSlice()
 	}
+	h.sum(f)
 	return nil
 }
 
+// sliceHash computes md5 of the whole data and of each slice written to it
+type sliceHash struct {
+	global hash.Hash
+	detail hash.Hash
+	// upper hex md5 of slices, joined to compute slice md5 of the file
+	slices []string
+	// base64 md5 of slices, names of upload parts
+	parts []string
+}
+
+func newSliceHash() *sliceHash {
+	return &sliceHash{global: md5.New(), detail: md5.New()}
+}
+
+func (h *sliceHash) Write(p []byte) (int, error) {
+	h.global.Write(p)
+	return h.detail.Write(p)
+}
+
+// endSlice ends the slice written since last call
+func (h *sliceHash) endSlice() {
+	v := h.detail.Sum(nil)
+	h.slices = append(h.slices, strings.ToUpper(hex.EncodeToString(v)))
+	h.parts = append(h.parts, base64.StdEncoding.EncodeToString(v))
+	h.detail.Reset()
+}
+
+// sum sets md5 of the file, its slices and part names on f
+func (h *sliceHash) sum(f *LocalFile) {
+	f.sliceNum = len(h.slices)
+	f.partName = h.parts
+	f.fileMD5 = hex.EncodeToString(h.global.Sum(nil))
+	f.sliceMD5 = f.fileMD5
+	if len(h.slices) > 1 {
+		h.detail.Write([]byte(strings.Join(h.slices, "\n")))
+		f.sliceMD5 = hex.EncodeToString(h.detail.Sum(nil))
+	}
+}
+
+// PartBody returns function making a new body of part for each upload attempt, and the body size
+func PartBody(part pkg.UploadPart) (func() io.Reader, int64, error) {
+	switch data := part.Data().(type) {
+	case *bytes.Buffer:
+		b := data.Bytes()
+		return func() io.Reader { return bytes.NewReader(b) }, int64(len(b)), nil
+	case *io.SectionReader:
+		return func() io.Reader { return io.NewSectionReader(data, 0, data.Size()) }, data.Size(), nil
+	default:
+		b, err := io.ReadAll(data)
+		if err != nil {
+			return nil, 0, err
+		}
+		return func() io.Reader { return bytes.NewReader(b) }, int64(len(b)), nil
+	}
+}
+
 type FilePart struct {
 	num  int64
 	name string
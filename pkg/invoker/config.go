@@ -1,18 +1,20 @@
 package invoker
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"os"
+	"sort"
+	"sync"
 
+	"github.com/gowsp/cloud189/pkg/auth"
 	"github.com/gowsp/cloud189/pkg/util"
 )
 
-type User struct {
-	Name     string `json:"name,omitempty"`
-	Password string `json:"password,omitempty"`
-}
 type RsaConfig struct {
 	ResCode int32  `json:"res_code,omitempty"`
 	Expire  int64  `json:"expire,omitempty"`
@@ -57,13 +59,27 @@ func (s *Session) Empty() bool {
 	return s == nil || s.Key == "" || s.Secret == ""
 }
 
+// DefaultProfile is the account stored at the top level of config
+const DefaultProfile = "default"
+
 type Config struct {
 	path    string
+	mu      sync.Mutex
+	owner   *Config
 	User    *User     `json:"user,omitempty"`
 	RSA     RsaConfig `json:"rsa,omitempty"`
 	SSON    string    `json:"sson,omitempty"`
 	Auth    string    `json:"auth,omitempty"`
 	Session *Session  `json:"session,omitempty"`
+	// named accounts besides the default one
+	Profiles map[string]*Config `json:"profiles,omitempty"`
+	Current  string             `json:"current,omitempty"`
+	// command keeping passwords in git-credential style instead of config
+	CredentialHelper string `json:"credentialHelper,omitempty"`
+	// accounts of webdav server, shared by all profiles
+	Webdav []*auth.User `json:"webdav,omitempty"`
+	// key of signed share links, generated on first use
+	ShareSecret string `json:"shareSecret,omitempty"`
 }
 
 func DefaultPath() string {
@@ -92,11 +108,15 @@ func OpenConfig(path string) (*Config, error) {
 	if file == "" {
 		file = DefaultPath()
 	}
-	f, err := os.OpenFile(file, os.O_CREATE|os.O_RDWR, 0644)
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_RDWR, 0600)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
+	// config keeps session keys and secrets, a looser mode of an existing file is tightened
+	if err = f.Chmod(0600); err != nil {
+		return nil, err
+	}
 	var config Config
 	err = json.NewDecoder(f).Decode(&config)
 	if err == io.EOF {
@@ -106,16 +126,163 @@ func OpenConfig(path string) (*Config, error) {
 		return nil, err
 	}
 	config.path = path
-	return &config, nil
+	migrate := false
+	for _, conf := range config.accounts() {
+		if conf.User == nil {
+			continue
+		}
+		migrate = migrate || conf.User.legacy
+		if err := conf.User.open(); err != nil {
+			log.Printf("%v, login again", err)
+		}
+	}
+	if migrate {
+		// rewrite plaintext password of old config
+		err = config.Save()
+	}
+	return &config, err
+}
+
+func (config *Config) accounts() []*Config {
+	result := []*Config{config}
+	for _, profile := range config.Profiles {
+		result = append(result, profile)
+	}
+	return result
 }
 func (config *Config) Save() error {
+	if config.owner != nil {
+		return config.owner.Save()
+	}
 	if config.path == "" {
 		return nil
 	}
-	f, err := os.OpenFile(config.path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	config.mu.Lock()
+	defer config.mu.Unlock()
+	for _, conf := range config.accounts() {
+		if conf.User == nil {
+			continue
+		}
+		if err := conf.User.seal(config.CredentialHelper); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(config.path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
+	// keep the config private even it was created with looser mode
+	if err = f.Chmod(0600); err != nil {
+		return err
+	}
 	return json.NewEncoder(f).Encode(config)
 }
+
+// Profile returns config of the named profile, empty name means the current one
+func (config *Config) Profile(name string) (*Config, error) {
+	if name == "" {
+		name = config.Current
+	}
+	if name == "" || name == DefaultProfile {
+		return config, nil
+	}
+	profile, ok := config.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("profile %s does not exist", name)
+	}
+	profile.owner = config
+	return profile, nil
+}
+
+// ProfileNames returns the default and named profiles in order
+func (config *Config) ProfileNames() []string {
+	names := make([]string, 0, len(config.Profiles)+1)
+	for name := range config.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{DefaultProfile}, names...)
+}
+
+func (config *Config) AddProfile(name string) error {
+	if name == "" || name == DefaultProfile {
+		return fmt.Errorf("invalid profile name %q", name)
+	}
+	if _, ok := config.Profiles[name]; ok {
+		return fmt.Errorf("profile %s already exists", name)
+	}
+	if config.Profiles == nil {
+		config.Profiles = make(map[string]*Config)
+	}
+	config.Profiles[name] = &Config{}
+	return config.Save()
+}
+
+func (config *Config) RemoveProfile(name string) error {
+	if _, ok := config.Profiles[name]; !ok {
+		return fmt.Errorf("profile %s does not exist", name)
+	}
+	delete(config.Profiles, name)
+	if config.Current == name {
+		config.Current = ""
+	}
+	return config.Save()
+}
+
+func (config *Config) UseProfile(name string) error {
+	if _, err := config.Profile(name); err != nil {
+		return err
+	}
+	if name == DefaultProfile {
+		name = ""
+	}
+	config.Current = name
+	return config.Save()
+}
+
+// SetWebdavUser adds the webdav user or replaces the one of same name
+func (config *Config) SetWebdavUser(user *auth.User) error {
+	if config.owner != nil {
+		return config.owner.SetWebdavUser(user)
+	}
+	for i, u := range config.Webdav {
+		if u.Name == user.Name {
+			config.Webdav[i] = user
+			return config.Save()
+		}
+	}
+	config.Webdav = append(config.Webdav, user)
+	return config.Save()
+}
+
+func (config *Config) RemoveWebdavUser(name string) error {
+	if config.owner != nil {
+		return config.owner.RemoveWebdavUser(name)
+	}
+	for i, u := range config.Webdav {
+		if u.Name == name {
+			config.Webdav = append(config.Webdav[:i], config.Webdav[i+1:]...)
+			return config.Save()
+		}
+	}
+	return fmt.Errorf("webdav user %s does not exist", name)
+}
+
+// ShareKey returns key of signed share links, a new key is generated and saved when absent
+func (config *Config) ShareKey() ([]byte, error) {
+	if config.owner != nil {
+		return config.owner.ShareKey()
+	}
+	if config.ShareSecret == "" {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		config.ShareSecret = base64.StdEncoding.EncodeToString(key)
+		if err := config.Save(); err != nil {
+			return nil, err
+		}
+	}
+	return base64.StdEncoding.DecodeString(config.ShareSecret)
+}
@@ -0,0 +1,220 @@
+package invoker
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/gowsp/cloud189/pkg/auth"
+)
+
+func TestProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	conf, err := OpenConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.User = &User{Name: "default"}
+	if err = conf.AddProfile("work"); err != nil {
+		t.Fatal(err)
+	}
+	work, err := conf.Profile("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	work.User = &User{Name: "work"}
+	if err = work.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err = conf.UseProfile("work"); err != nil {
+		t.Fatal(err)
+	}
+
+	conf, err = OpenConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.User.Name != "default" {
+		t.Errorf("default profile user %s", conf.User.Name)
+	}
+	current, err := conf.Profile("")
+	if err != nil || current.User.Name != "work" {
+		t.Errorf("current profile %v, %v", current, err)
+	}
+	if err = conf.RemoveProfile("work"); err != nil {
+		t.Fatal(err)
+	}
+	if current, _ = conf.Profile(""); current != conf {
+		t.Error("removed current profile should fall back to default")
+	}
+}
+
+func TestMigratePassword(t *testing.T) {
+	t.Setenv(PassphraseEnv, "test")
+	path := filepath.Join(t.TempDir(), "config.json")
+	legacy := `{"user":{"name":"demo","password":"secret"}}`
+	if err := os.WriteFile(path, []byte(legacy), 0666); err != nil {
+		t.Fatal(err)
+	}
+	conf, err := OpenConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.User.Password != "secret" {
+		t.Errorf("password %s", conf.User.Password)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), `"password"`) || !strings.Contains(string(data), `"secret":`) {
+		t.Errorf("config not migrated: %s", data)
+	}
+	if info, _ := os.Stat(path); runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("config mode %v", info.Mode())
+	}
+	conf, err = OpenConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if password, _ := conf.Password(); password != "secret" {
+		t.Errorf("decrypted password %s", password)
+	}
+	t.Setenv(PassphraseEnv, "other")
+	if conf, _ = OpenConfig(path); conf.User.Password != "" {
+		t.Error("password should not decrypt with other passphrase")
+	}
+}
+
+func TestCredentialHelper(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("helper script requires sh")
+	}
+	dir := t.TempDir()
+	store := filepath.Join(dir, "store")
+	script := filepath.Join(dir, "helper")
+	helper := "#!/bin/sh\ncase $1 in\nget) echo password=$(cat " + store + ");;\nstore) sed -n 's/^password=//p' > " + store + ";;\nerase) rm -f " + store + ";;\nesac\n"
+	if err := os.WriteFile(script, []byte(helper), 0700); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "config.json")
+	conf, _ := OpenConfig(path)
+	conf.CredentialHelper = script
+	conf.User = &User{Name: "demo", Password: "secret"}
+	if err := conf.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); strings.Contains(string(data), "secret") {
+		t.Errorf("password saved in config: %s", data)
+	}
+	conf, _ = OpenConfig(path)
+	if password, err := conf.Password(); err != nil || password != "secret" {
+		t.Errorf("helper password %s, %v", password, err)
+	}
+	// re-login with the same password leaves the helper alone
+	os.Remove(store)
+	conf.SetUser("demo", "secret")
+	if err := conf.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(store); !os.IsNotExist(err) {
+		t.Error("unchanged password stored again")
+	}
+	conf.SetUser("demo", "changed")
+	if err := conf.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(store); strings.TrimSpace(string(data)) != "changed" {
+		t.Errorf("changed password stored %q", data)
+	}
+	if err := conf.ClearUser(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(store); !os.IsNotExist(err) {
+		t.Error("password not erased")
+	}
+}
+
+func TestCredentialHelperAction(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("helper script requires sh")
+	}
+	dir := t.TempDir()
+	args := filepath.Join(dir, "args")
+	// the helper may carry its own arguments, the action comes last
+	helper := "printf '%s|' > " + args
+	if _, err := credential(helper+" --store", "erase", "demo", ""); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(args); string(data) != "--store|erase|" {
+		t.Errorf("helper args %q", data)
+	}
+}
+
+func TestConfigMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file mode is not supported")
+	}
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"sson":"x"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenConfig(path); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("config mode %v", info.Mode().Perm())
+	}
+}
+
+func TestWebdavUser(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	conf, err := OpenConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = conf.AddProfile("work"); err != nil {
+		t.Fatal(err)
+	}
+	work, _ := conf.Profile("work")
+	user, err := auth.NewUser("alice", "secret", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = work.SetWebdavUser(user); err != nil {
+		t.Fatal(err)
+	}
+	user.Root = "/photos"
+	if err = conf.SetWebdavUser(user); err != nil {
+		t.Fatal(err)
+	}
+	conf, err = OpenConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conf.Webdav) != 1 || conf.Webdav[0].Root != "/photos" || strings.Contains(conf.Webdav[0].Hash, "secret") || conf.Webdav[0].Digest != "" {
+		t.Errorf("webdav users %+v", conf.Webdav)
+	}
+	if err = conf.RemoveWebdavUser("alice"); err != nil || len(conf.Webdav) != 0 {
+		t.Errorf("remove webdav user: %v", err)
+	}
+}
+
+func TestShareKey(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	config, err := OpenConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := config.ShareKey()
+	if err != nil || len(key) != 32 {
+		t.Fatalf("key %x, %v", key, err)
+	}
+	config, err = OpenConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved, _ := config.ShareKey(); !bytes.Equal(saved, key) {
+		t.Errorf("saved key %x, want %x", saved, key)
+	}
+}
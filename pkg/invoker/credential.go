@@ -0,0 +1,244 @@
+package invoker
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// PassphraseEnv names the env holding the passphrase which encrypts the saved password,
+// a secret of this machine is used when it is empty
+const PassphraseEnv = "CLOUD189_PASSPHRASE"
+
+type User struct {
+	Name     string `json:"name,omitempty"`
+	Password string `json:"-"`
+	// password encrypted by passphrase or machine secret
+	Secret string `json:"secret,omitempty"`
+	// plaintext password read from old config
+	legacy bool
+	// password is kept by credential helper
+	stored bool
+	// password the secret was sealed from
+	sealed string
+}
+
+func (u *User) UnmarshalJSON(b []byte) error {
+	type user User
+	var raw struct {
+		user
+		Password string `json:"password"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	*u = User(raw.user)
+	u.Password = raw.Password
+	u.legacy = raw.Password != ""
+	return nil
+}
+
+// open decrypts the secret read from config
+func (u *User) open() error {
+	if u.Secret == "" {
+		return nil
+	}
+	password, err := decrypt(u.Secret)
+	if err != nil {
+		return fmt.Errorf("decrypt password of %s: %w", u.Name, err)
+	}
+	u.Password, u.sealed = password, password
+	return nil
+}
+
+// seal moves password out of plaintext before saving config
+func (u *User) seal(helper string) error {
+	if helper != "" {
+		u.Secret, u.sealed = "", ""
+		if u.Password == "" || u.stored {
+			return nil
+		}
+		if _, err := credential(helper, "store", u.Name, u.Password); err != nil {
+			return err
+		}
+		u.stored = true
+		return nil
+	}
+	if u.Password == "" {
+		u.Secret, u.sealed = "", ""
+		return nil
+	}
+	if u.Secret != "" && u.sealed == u.Password {
+		return nil
+	}
+	secret, err := encrypt(u.Password)
+	if err != nil {
+		return err
+	}
+	u.Secret, u.sealed = secret, u.Password
+	return nil
+}
+
+func (config *Config) helper() string {
+	if config.owner != nil {
+		return config.owner.CredentialHelper
+	}
+	return config.CredentialHelper
+}
+
+// Password returns the saved password, asks the credential helper when configured
+func (config *Config) Password() (string, error) {
+	user := config.User
+	if user == nil || user.Name == "" {
+		return "", nil
+	}
+	helper := config.helper()
+	if user.Password != "" || helper == "" || user.stored {
+		return user.Password, nil
+	}
+	password, err := credential(helper, "get", user.Name, "")
+	if err != nil {
+		return "", err
+	}
+	user.Password, user.stored = password, true
+	return password, nil
+}
+
+// SetUser sets the account logged in, the same account keeps its state so that
+// a password kept by credential helper is not stored again on each re-login
+func (config *Config) SetUser(name, password string) {
+	if u := config.User; u != nil && u.Name == name && u.Password == password {
+		return
+	}
+	config.User = &User{Name: name, Password: password}
+}
+
+// ClearUser removes the saved account, erases it from credential helper as well
+func (config *Config) ClearUser() error {
+	user := config.User
+	config.User = &User{}
+	if helper := config.helper(); helper != "" && user != nil && user.Name != "" {
+		_, err := credential(helper, "erase", user.Name, "")
+		return err
+	}
+	return nil
+}
+
+// credential runs helper in git-credential style, action is get, store or erase
+func credential(helper, action, username, password string) (string, error) {
+	var input bytes.Buffer
+	input.WriteString("protocol=https\nhost=cloud.189.cn\n")
+	fmt.Fprintf(&input, "username=%s\n", username)
+	if password != "" {
+		fmt.Fprintf(&input, "password=%s\n", password)
+	}
+	input.WriteString("\n")
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		// action is one of the fixed words above, nothing to quote
+		cmd = exec.Command("cmd", "/C", helper+" "+action)
+	} else {
+		// action is passed as argument instead of joined into the script
+		cmd = exec.Command("sh", "-c", helper+` "$1"`, "sh", action)
+	}
+	cmd.Stdin = &input
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("credential helper %s: %w", action, err)
+	}
+	if action != "get" {
+		return "", nil
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "password="); ok {
+			return value, nil
+		}
+	}
+	return "", errors.New("credential helper returns no password")
+}
+
+const saltSize = 16
+
+func passphrase() []byte {
+	if val := os.Getenv(PassphraseEnv); val != "" {
+		return []byte(val)
+	}
+	var secret []byte
+	for _, name := range []string{"/etc/machine-id", "/var/lib/dbus/machine-id"} {
+		if data, err := os.ReadFile(name); err == nil {
+			secret = bytes.TrimSpace(data)
+			break
+		}
+	}
+	host, _ := os.Hostname()
+	home, _ := os.UserHomeDir()
+	return append(secret, host+home...)
+}
+
+func newGCM(salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase(), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encrypt returns base64 of salt, nonce and sealed data
+func encrypt(data string) (string, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(salt)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	out := append(salt, nonce...)
+	out = gcm.Seal(out, nonce, []byte(data), nil)
+	return base64.StdEncoding.EncodeToString(out), nil
+}
+
+func decrypt(secret string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < saltSize {
+		return "", errors.New("invalid secret")
+	}
+	gcm, err := newGCM(data[:saltSize])
+	if err != nil {
+		return "", err
+	}
+	data = data[saltSize:]
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("invalid secret")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
@@ -3,13 +3,13 @@ package invoker
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/cookiejar"
 	"net/http/httputil"
 	"net/url"
 	"os"
 	"strings"
-	"time"
 )
 
 type Invoker struct {
@@ -17,6 +17,7 @@ type Invoker struct {
 	http    *http.Client
 	conf    *Config
 	prepare func(*http.Request)
+	retry   RetryPolicy
 	Refresh func() error
 }
 
@@ -27,7 +28,17 @@ func NewInvoker(apiUrl string, refresh func() error, conf *Config) *Invoker {
 	jar.SetCookies(&url.URL{Scheme: "https", Host: "e.189.cn"}, sson)
 	jar.SetCookies(&url.URL{Scheme: "https", Host: "cloud.189.cn"}, user)
 	jar.SetCookies(&url.URL{Scheme: "https", Host: "m.cloud.189.cn"}, user)
-	return &Invoker{url: apiUrl, Refresh: refresh, http: &http.Client{Jar: jar}, conf: conf}
+	i := &Invoker{url: apiUrl, Refresh: refresh, http: &http.Client{Jar: jar}, conf: conf}
+	i.SetRetry(DefaultRetryPolicy)
+	return i
+}
+
+// SetRetry changes retry policy of requests
+func (i *Invoker) SetRetry(policy RetryPolicy) {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 1
+	}
+	i.retry = policy
 }
 
 func (i *Invoker) SetPrepare(prepare func(req *http.Request)) {
@@ -61,25 +72,47 @@ func (i *Invoker) DoWithResp(req *http.Request) (*http.Response, error) {
 	}
 	return resp, err
 }
-func (i *Invoker) Do(req *http.Request, data any, retry int) error {
-	if retry == 0 {
-		return os.ErrInvalid
-	}
-	resp, err := i.DoWithResp(req)
-	if err != nil || resp.StatusCode == http.StatusBadRequest {
-		time.Sleep(time.Millisecond * 200)
-		err := i.Refresh()
+
+// Do sends req with retry policy and decodes json response into data,
+// body of req is reset by GetBody between attempts
+func (i *Invoker) Do(req *http.Request, data any) error {
+	first := true
+	return i.Request(func() (*http.Request, error) {
+		if first {
+			first = false
+			return req, nil
+		}
+		retry := req.Clone(req.Context())
+		// cookies are set by jar again
+		retry.Header.Del("Cookie")
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			retry.Body = body
+		}
+		return retry, nil
+	}, data)
+}
+
+// Request sends request made by build with retry policy and decodes json response into data,
+// error codes of session and throttle in body are retried, other ones are left to data
+func (i *Invoker) Request(build func() (*http.Request, error), data any) error {
+	return i.Retry(build, func(resp *http.Response) error {
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
 		if err != nil {
 			return err
 		}
-		req.Header.Del("Cookie")
-		if req.GetBody != nil {
-			req.Body, _ = req.GetBody()
+		if err = BodyError(resp, body); err != nil {
+			return err
 		}
-		return i.Do(req, data, retry-1)
-	}
-	defer resp.Body.Close()
-	return json.NewDecoder(resp.Body).Decode(data)
+		if data == nil {
+			return nil
+		}
+		return json.Unmarshal(body, data)
+	})
 }
 
 func (i *Invoker) Send(req *http.Request) (*http.Response, error) {
@@ -98,7 +131,7 @@ func (i *Invoker) Get(path string, params url.Values, data any) error {
 		return err
 	}
 	req.Header.Set("Accept", "application/json;charset=UTF-8")
-	return i.Do(req, data, 3)
+	return i.Do(req, data)
 }
 func (i *Invoker) Post(path string, params url.Values, data any) error {
 	url := i.url + path
@@ -108,5 +141,5 @@ func (i *Invoker) Post(path string, params url.Values, data any) error {
 	}
 	req.Header.Set("Accept", "application/json;charset=UTF-8")
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	return i.Do(req, data, 3)
+	return i.Do(req, data)
 }
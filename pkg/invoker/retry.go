@@ -0,0 +1,318 @@
+package invoker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gowsp/cloud189/pkg"
+)
+
+type ErrorClass int
+
+// class of a failed request, decides whether and how it is retried
+const (
+	// not retried
+	Permanent ErrorClass = iota
+	// session expired, retried once after refresh
+	Auth
+	// rate limited by server, retried after backoff or Retry-After
+	Throttle
+	// network error, timeout or server error, retried after backoff
+	Transient
+)
+
+func (c ErrorClass) String() string {
+	switch c {
+	case Auth:
+		return "auth"
+	case Throttle:
+		return "throttle"
+	case Transient:
+		return "transient"
+	}
+	return "permanent"
+}
+
+// Error is a failed request with its class
+type Error struct {
+	Class  ErrorClass
+	Status int
+	// error code in response body, such as res_code or code
+	Code    string
+	Message string
+	// delay asked by server
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" && e.Err != nil {
+		msg = e.Err.Error()
+	}
+	switch {
+	case e.Code != "" && msg != "":
+		return fmt.Sprintf("%s: %s", e.Code, msg)
+	case e.Code != "":
+		return e.Code
+	case e.Status != 0 && msg != "":
+		return fmt.Sprintf("status %d: %s", e.Status, msg)
+	case e.Status != 0:
+		return fmt.Sprintf("status %d %s", e.Status, http.StatusText(e.Status))
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// class of error code in response body
+func codeClass(code string) ErrorClass {
+	switch (&pkg.Error{Code: code}).Kind() {
+	case pkg.ErrSessionExpired:
+		return Auth
+	case pkg.ErrRateLimited:
+		return Throttle
+	}
+	return Permanent
+}
+
+// Classify returns class of err returned by a request
+func Classify(err error) ErrorClass {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Class
+	}
+	if errors.Is(err, context.Canceled) {
+		return Permanent
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return Transient
+	}
+	return Permanent
+}
+
+// StatusError classifies response by status code, nil when it succeeds
+func StatusError(resp *http.Response) error {
+	status := resp.StatusCode
+	if status < 300 {
+		return nil
+	}
+	e := &Error{Status: status, Class: Permanent}
+	switch {
+	case status == http.StatusTooManyRequests:
+		e.Class = Throttle
+		e.RetryAfter = retryAfter(resp.Header.Get("Retry-After"))
+	case status == http.StatusUnauthorized:
+		e.Class = Auth
+	case status >= 500:
+		e.Class = Transient
+	}
+	return e
+}
+
+// CheckStatus is a check of Retry accepting successful status only,
+// body of failed response is kept as message
+func CheckStatus(resp *http.Response) error {
+	defer resp.Body.Close()
+	err := StatusError(resp)
+	if err != nil {
+		data, _ := io.ReadAll(resp.Body)
+		err.(*Error).Message = string(data)
+	}
+	return err
+}
+
+// BodyError classifies error code in json body of response, nil when there is none
+func BodyError(resp *http.Response, body []byte) error {
+	var result struct {
+		ResCode    any    `json:"res_code"`
+		ResMessage string `json:"res_message"`
+		Code       string `json:"code"`
+		Msg        string `json:"msg"`
+		ErrorCode  string `json:"errorCode"`
+	}
+	json.Unmarshal(body, &result)
+	code, msg := result.ErrorCode, result.Msg
+	if result.Code != "" && result.Code != "SUCCESS" {
+		code = result.Code
+	}
+	if s := fmt.Sprint(result.ResCode); result.ResCode != nil && s != "0" {
+		if code == "" {
+			code = s
+		}
+		msg = result.ResMessage
+	}
+	status := StatusError(resp)
+	switch class := codeClass(code); {
+	case class == Auth:
+		return &Error{Class: Auth, Status: resp.StatusCode, Code: code, Message: msg}
+	case class == Throttle:
+		return &Error{Class: Throttle, Status: resp.StatusCode, Code: code, Message: msg,
+			RetryAfter: retryAfter(resp.Header.Get("Retry-After"))}
+	case status != nil:
+		e := status.(*Error)
+		// expired session of old api is responded with bad request without code
+		if e.Status == http.StatusBadRequest && code == "" {
+			e.Class = Auth
+		}
+		e.Code, e.Message = code, msg
+		return e
+	}
+	return nil
+}
+
+func retryAfter(val string) time.Duration {
+	if seconds, err := strconv.Atoi(val); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(val); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// RetryPolicy is exponential backoff with jitter
+type RetryPolicy struct {
+	// max attempts of a request, including the first one
+	MaxAttempts int
+	// delay before the first retry, doubled each retry
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// give up once the time since the first attempt exceeds it, zero means no limit
+	MaxElapsed time.Duration
+	// random fraction of delay added or removed, 0 to 1
+	Jitter float64
+}
+
+// DefaultRetryPolicy is copied by new invokers
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   200 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+	MaxElapsed:  2 * time.Minute,
+	Jitter:      0.5,
+}
+
+// Backoff returns delay before the given retry, starting from 1
+func (p *RetryPolicy) Backoff(retry int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < retry && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		delay += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(delay))
+	}
+	return delay
+}
+
+// Retry sends request made by build until check accepts the response or the policy gives up,
+// build is called for each attempt, check reads the response and closes its body
+func (i *Invoker) Retry(build func() (*http.Request, error), check func(*http.Response) error) error {
+	return i.retryWith(i.DoWithResp, build, check)
+}
+
+// SendRetry is Retry without signing requests, such as uploading to a presigned url
+func (i *Invoker) SendRetry(build func() (*http.Request, error), check func(*http.Response) error) error {
+	return i.retryWith(i.Send, build, check)
+}
+
+// Open sends download request made by build with retry policy, returns the successful response with its body open,
+// failures after the response is returned, such as a broken stream, are left to caller
+func (i *Invoker) Open(build func() (*http.Request, error)) (*http.Response, error) {
+	var result *http.Response
+	err := i.retryWith(i.Send, build, func(resp *http.Response) error {
+		if err := StatusError(resp); err != nil {
+			resp.Body.Close()
+			return err
+		}
+		result = resp
+		return nil
+	})
+	return result, err
+}
+
+func (i *Invoker) retryWith(do func(*http.Request) (*http.Response, error),
+	build func() (*http.Request, error), check func(*http.Response) error) error {
+	start := time.Now()
+	refreshed := false
+	for attempt := 1; ; attempt++ {
+		req, err := build()
+		if err != nil {
+			return err
+		}
+		resp, err := do(req)
+		if err == nil {
+			err = check(resp)
+		}
+		if err == nil {
+			return nil
+		}
+		class := Classify(err)
+		if class == Auth {
+			// refresh once regardless of attempts, another auth error is not recoverable
+			if refreshed || i.Refresh == nil {
+				return apiError(req, err)
+			}
+			refreshed = true
+			if err := i.Refresh(); err != nil {
+				return err
+			}
+			continue
+		}
+		if class == Permanent || attempt >= i.retry.MaxAttempts {
+			return apiError(req, err)
+		}
+		// a request such as creating a task may have been done by server, send it again only when it never arrived
+		if class == Transient && !replayable(req) && !unsent(err) {
+			return apiError(req, err)
+		}
+		delay := i.retry.Backoff(attempt)
+		var e *Error
+		if errors.As(err, &e) && e.RetryAfter > delay {
+			delay = e.RetryAfter
+		}
+		if i.retry.MaxElapsed > 0 && time.Since(start)+delay > i.retry.MaxElapsed {
+			return apiError(req, err)
+		}
+		time.Sleep(delay)
+	}
+}
+
+// replayable reports whether req is idempotent, by method or an Idempotency-Key header as net/http does,
+// a header with nil value marks the request without sending it
+func replayable(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	_, key := req.Header["Idempotency-Key"]
+	_, xkey := req.Header["X-Idempotency-Key"]
+	return key || xkey
+}
+
+// unsent reports whether err happened before request reached server, such as a refused connection
+func unsent(err error) bool {
+	var op *net.OpError
+	return errors.As(err, &op) && op.Op == "dial"
+}
+
+// apiError turns classified error of req into pkg.Error, which keeps the class
+func apiError(req *http.Request, err error) error {
+	var e *Error
+	if !errors.As(err, &e) {
+		return err
+	}
+	return &pkg.Error{Op: req.URL.Path, Code: e.Code, Message: e.Message, Status: e.Status, Err: err}
+}
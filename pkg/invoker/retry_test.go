@@ -0,0 +1,210 @@
+package invoker
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gowsp/cloud189/pkg"
+)
+
+func response(status int, header http.Header) *http.Response {
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{StatusCode: status, Header: header, Body: io.NopCloser(strings.NewReader(""))}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		resp  *http.Response
+		body  string
+		class ErrorClass
+	}{
+		{response(500, nil), "", Transient},
+		{response(503, nil), "", Transient},
+		{response(429, http.Header{"Retry-After": {"3"}}), "", Throttle},
+		{response(401, nil), "", Auth},
+		{response(400, nil), "", Auth},
+		{response(404, nil), "", Permanent},
+		{response(400, nil), `{"res_code":"FileNotFound","res_message":"not found"}`, Permanent},
+		{response(200, nil), `{"res_code":"InvalidSessionKey"}`, Auth},
+		{response(200, nil), `{"code":"ServerBusy"}`, Throttle},
+		{response(200, nil), `{"errorCode":"InvalidSessionKey"}`, Auth},
+	}
+	for _, test := range tests {
+		err := BodyError(test.resp, []byte(test.body))
+		if err == nil {
+			t.Errorf("status %d %s: no error", test.resp.StatusCode, test.body)
+			continue
+		}
+		if class := Classify(err); class != test.class {
+			t.Errorf("status %d %s: class %s, want %s", test.resp.StatusCode, test.body, class, test.class)
+		}
+	}
+	if err := BodyError(response(200, nil), []byte(`{"res_code":0}`)); err != nil {
+		t.Errorf("success: %v", err)
+	}
+	if err := BodyError(response(200, nil), []byte(`{"code":"SUCCESS"}`)); err != nil {
+		t.Errorf("success: %v", err)
+	}
+	// business errors of successful response are left to caller
+	if err := BodyError(response(200, nil), []byte(`{"res_code":"FileAlreadyExists"}`)); err != nil {
+		t.Errorf("business error: %v", err)
+	}
+	err := StatusError(response(429, http.Header{"Retry-After": {"3"}}))
+	if e := err.(*Error); e.RetryAfter != 3*time.Second {
+		t.Errorf("retry after %s", e.RetryAfter)
+	}
+	netErr := &url.Error{Op: "Get", URL: "x", Err: timeoutError{}}
+	if class := Classify(netErr); class != Transient {
+		t.Errorf("network error class %s", class)
+	}
+	if class := Classify(errors.New("bad")); class != Permanent {
+		t.Errorf("plain error class %s", class)
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	want := []time.Duration{100, 200, 400, 800, 1000, 1000}
+	for i, w := range want {
+		if d := p.Backoff(i + 1); d != w*time.Millisecond {
+			t.Errorf("retry %d: delay %s, want %s", i+1, d, w*time.Millisecond)
+		}
+	}
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if d := p.Backoff(2); d < 100*time.Millisecond || d > 300*time.Millisecond {
+			t.Fatalf("jittered delay %s out of range", d)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch r.URL.Path {
+		case "/flaky":
+			if calls < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		case "/session":
+			if calls == 1 {
+				fmt.Fprint(w, `{"res_code":"InvalidSessionKey"}`)
+				return
+			}
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, `{"res_code":0,"body":%q}`, body)
+	}))
+	defer server.Close()
+
+	refreshed := 0
+	i := &Invoker{http: server.Client(), Refresh: func() error { refreshed++; return nil }}
+	i.SetRetry(RetryPolicy{MaxAttempts: 4, BaseDelay: time.Millisecond})
+	var result struct {
+		Body string `json:"body"`
+	}
+	req, _ := http.NewRequest(http.MethodPut, server.URL+"/flaky", strings.NewReader("data"))
+	if err := i.Do(req, &result); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 || result.Body != "data" || refreshed != 0 {
+		t.Errorf("flaky: calls %d, body %q, refreshed %d", calls, result.Body, refreshed)
+	}
+
+	// server error of post may come after it is done
+	calls = 0
+	req, _ = http.NewRequest(http.MethodPost, server.URL+"/flaky", strings.NewReader("data"))
+	if err := i.Do(req, &result); Classify(err) != Transient || calls != 1 {
+		t.Errorf("post: calls %d, err %v", calls, err)
+	}
+	calls = 0
+	req, _ = http.NewRequest(http.MethodPost, server.URL+"/flaky", strings.NewReader("data"))
+	req.Header["Idempotency-Key"] = nil
+	if err := i.Do(req, &result); err != nil || calls != 3 {
+		t.Errorf("idempotent post: calls %d, err %v", calls, err)
+	}
+
+	calls = 0
+	req, _ = http.NewRequest(http.MethodGet, server.URL+"/session", nil)
+	if err := i.Do(req, &result); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 || refreshed != 1 {
+		t.Errorf("session: calls %d, refreshed %d", calls, refreshed)
+	}
+
+	calls = 0
+	req, _ = http.NewRequest(http.MethodGet, server.URL+"/missing", nil)
+	err := i.Do(req, &result)
+	var e *Error
+	if !errors.As(err, &e) || e.Status != http.StatusNotFound || calls != 1 || !errors.Is(err, pkg.ErrNotFound) {
+		t.Errorf("missing: calls %d, err %v", calls, err)
+	}
+
+	// session is refreshed even if retry is disabled
+	calls, refreshed = 0, 0
+	i.SetRetry(RetryPolicy{MaxAttempts: 1})
+	req, _ = http.NewRequest(http.MethodGet, server.URL+"/session", nil)
+	if err := i.Do(req, &result); err != nil || calls != 2 || refreshed != 1 {
+		t.Errorf("session without retry: calls %d, refreshed %d, err %v", calls, refreshed, err)
+	}
+
+	calls = 0
+	i.SetRetry(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond})
+	err = i.SendRetry(func() (*http.Request, error) {
+		return http.NewRequest(http.MethodPut, server.URL+"/flaky", strings.NewReader("part"))
+	}, CheckStatus)
+	if Classify(err) != Transient || calls != 2 {
+		t.Errorf("attempts: calls %d, err %v", calls, err)
+	}
+
+	calls = 0
+	i.SetRetry(RetryPolicy{MaxAttempts: 4, BaseDelay: time.Millisecond})
+	resp, err := i.Open(func() (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, server.URL+"/flaky", nil)
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("open: calls %d, err %v", calls, err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "res_code") {
+		t.Errorf("open: body %q", body)
+	}
+}
+
+func TestRetryUnsent(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	i := &Invoker{http: http.DefaultClient}
+	i.SetRetry(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	attempts := 0
+	err := i.Retry(func() (*http.Request, error) {
+		attempts++
+		return http.NewRequest(http.MethodPost, server.URL+"/createBatchTask.action", strings.NewReader("data"))
+	}, CheckStatus)
+	// refused connection never reaches server, post is safe to send again
+	if err == nil || attempts != 3 {
+		t.Errorf("attempts %d, err %v", attempts, err)
+	}
+}
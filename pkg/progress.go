@@ -0,0 +1,251 @@
+package pkg
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type ProgressKind int
+
+// kind of transfer progress event
+const (
+	ProgressStart ProgressKind = iota + 1
+	ProgressBytes
+	ProgressPart
+	ProgressFinish
+	ProgressError
+)
+
+func (k ProgressKind) String() string {
+	switch k {
+	case ProgressStart:
+		return "start"
+	case ProgressBytes:
+		return "bytes"
+	case ProgressPart:
+		return "part"
+	case ProgressFinish:
+		return "finish"
+	case ProgressError:
+		return "error"
+	}
+	return fmt.Sprintf("unknown(%d)", int(k))
+}
+
+func (k ProgressKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// operation of transfer
+const (
+	OpUpload   = "upload"
+	OpDownload = "download"
+)
+
+type ProgressEvent struct {
+	// id of the transfer, unique in the process
+	Id    uint64       `json:"id"`
+	Kind  ProgressKind `json:"kind"`
+	Op    string       `json:"op"`
+	Name  string       `json:"name"`
+	Total int64        `json:"total"`
+	Done  int64        `json:"done"`
+	// number of parts done and all parts, upload only
+	Part  int `json:"part,omitempty"`
+	Parts int `json:"parts,omitempty"`
+	// bytes per second
+	Speed float64       `json:"speed"`
+	ETA   time.Duration `json:"eta"`
+	Error string        `json:"error,omitempty"`
+}
+
+type Progress interface {
+	OnProgress(ProgressEvent)
+}
+
+type ProgressFunc func(ProgressEvent)
+
+func (f ProgressFunc) OnProgress(e ProgressEvent) { f(e) }
+
+// interval of bytes events of a transfer
+const progressInterval = 200 * time.Millisecond
+
+// Transfer tracks bytes of a file and emits progress events, methods of nil Transfer do nothing
+type Transfer struct {
+	mu       sync.Mutex
+	progress Progress
+	event    ProgressEvent
+	// bytes done before start, such as resumed download
+	base  int64
+	start time.Time
+	last  time.Time
+}
+
+// last id of transfers
+var transferIds atomic.Uint64
+
+// NewTransfer returns nil when p is nil
+func NewTransfer(p Progress, op, name string, total int64, parts int) *Transfer {
+	if p == nil {
+		return nil
+	}
+	return &Transfer{
+		progress: p,
+		event:    ProgressEvent{Id: transferIds.Add(1), Op: op, Name: name, Total: total, Parts: parts},
+	}
+}
+
+// Id returns id of the transfer in its events
+func (t *Transfer) Id() uint64 {
+	if t == nil {
+		return 0
+	}
+	return t.event.Id
+}
+
+// Start emits start event, done is the bytes already transferred
+func (t *Transfer) Start(done int64) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.start, t.last = time.Now(), time.Now()
+	t.base, t.event.Done = done, done
+	t.emit(ProgressStart)
+}
+
+func (t *Transfer) Add(n int64) {
+	if t == nil || n <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.add(n)
+}
+
+func (t *Transfer) add(n int64) {
+	t.event.Done += n
+	if now := time.Now(); now.Sub(t.last) >= progressInterval {
+		t.last = now
+		t.emit(ProgressBytes)
+	}
+}
+
+// PartDone emits part event once a part is uploaded
+func (t *Transfer) PartDone() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.event.Part++
+	t.emit(ProgressPart)
+}
+
+// Finish emits finish event or error event when err is not nil
+func (t *Transfer) Finish(err error) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err != nil {
+		t.event.Error = err.Error()
+		t.emit(ProgressError)
+		return
+	}
+	t.event.Done = t.event.Total
+	t.emit(ProgressFinish)
+}
+
+// Reader counts bytes read from r
+func (t *Transfer) Reader(r io.Reader) io.Reader {
+	if t == nil {
+		return r
+	}
+	return &transferReader{Reader: r, t: t}
+}
+
+// Body wraps body of a part sent by retried requests, bytes counted by the previous attempt
+// are taken back when the body is built again
+func (t *Transfer) Body(body func() io.Reader) func() io.Reader {
+	if t == nil {
+		return body
+	}
+	var sent int64
+	return func() io.Reader {
+		t.mu.Lock()
+		t.event.Done -= sent
+		sent = 0
+		t.mu.Unlock()
+		return &transferReader{Reader: body(), t: t, sent: &sent}
+	}
+}
+
+func (t *Transfer) emit(kind ProgressKind) {
+	t.event.Kind = kind
+	if elapsed := time.Since(t.start).Seconds(); elapsed > 0 {
+		t.event.Speed = float64(t.event.Done-t.base) / elapsed
+	}
+	if t.event.Speed > 0 && t.event.Total > t.event.Done {
+		t.event.ETA = time.Duration(float64(t.event.Total-t.event.Done) / t.event.Speed * float64(time.Second))
+	} else {
+		t.event.ETA = 0
+	}
+	t.progress.OnProgress(t.event)
+}
+
+type transferReader struct {
+	io.Reader
+	t *Transfer
+	// bytes counted by the attempt of Body, nil for Reader
+	sent *int64
+}
+
+func (r *transferReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		r.t.mu.Lock()
+		if r.sent != nil {
+			*r.sent += int64(n)
+		}
+		r.t.add(int64(n))
+		r.t.mu.Unlock()
+	}
+	return n, err
+}
+
+// WithProgress attaches progress to upload, which is reported by the uploader
+func WithProgress(up Upload, p Progress) Upload {
+	if p == nil {
+		return up
+	}
+	return &progressUpload{Upload: up, progress: p}
+}
+
+// ProgressOf returns progress attached to the upload, nil if none
+func ProgressOf(up any) Progress {
+	for up != nil {
+		if p, ok := up.(interface{ Progress() Progress }); ok {
+			return p.Progress()
+		}
+		u, ok := up.(interface{ Unwrap() Upload })
+		if !ok {
+			return nil
+		}
+		up = u.Unwrap()
+	}
+	return nil
+}
+
+type progressUpload struct {
+	Upload
+	progress Progress
+}
+
+func (u *progressUpload) Progress() Progress { return u.progress }
+func (u *progressUpload) Unwrap() Upload     { return u.Upload }
@@ -0,0 +1,78 @@
+package pkg
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestTransfer(t *testing.T) {
+	var events []ProgressEvent
+	tr := NewTransfer(ProgressFunc(func(e ProgressEvent) {
+		events = append(events, e)
+	}), OpDownload, "a.txt", 10, 2)
+	tr.Start(4)
+	if _, err := io.Copy(io.Discard, tr.Reader(strings.NewReader("abc"))); err != nil {
+		t.Fatal(err)
+	}
+	tr.PartDone()
+	tr.Finish(nil)
+	if len(events) != 3 {
+		t.Fatalf("expect start, part and finish events, got %v", events)
+	}
+	if events[0].Kind != ProgressStart || events[0].Done != 4 {
+		t.Fatalf("unexpected start event %+v", events[0])
+	}
+	if events[1].Kind != ProgressPart || events[1].Done != 7 || events[1].Part != 1 {
+		t.Fatalf("unexpected part event %+v", events[1])
+	}
+	if events[2].Kind != ProgressFinish || events[2].Done != 10 {
+		t.Fatalf("unexpected finish event %+v", events[2])
+	}
+
+	tr = NewTransfer(ProgressFunc(func(e ProgressEvent) {
+		events = append(events, e)
+	}), OpUpload, "b.txt", 10, 1)
+	tr.Finish(errors.New("boom"))
+	if last := events[len(events)-1]; last.Kind != ProgressError || last.Error != "boom" {
+		t.Fatalf("unexpected error event %+v", last)
+	}
+
+	// nil transfer is a no-op
+	var none *Transfer
+	none.Start(0)
+	none.Finish(nil)
+	if NewTransfer(nil, OpUpload, "c", 1, 1) != nil {
+		t.Fatal("transfer without progress should be nil")
+	}
+}
+
+func TestTransferBody(t *testing.T) {
+	var last ProgressEvent
+	tr := NewTransfer(ProgressFunc(func(e ProgressEvent) { last = e }), OpUpload, "a.txt", 5, 1)
+	tr.Start(0)
+	body := tr.Body(func() io.Reader { return strings.NewReader("hello") })
+	// a failed attempt sends part of the body
+	io.CopyN(io.Discard, body(), 3)
+	io.Copy(io.Discard, body())
+	tr.PartDone()
+	if last.Done != 5 {
+		t.Fatalf("bytes of failed attempt are counted, done %d", last.Done)
+	}
+	var none *Transfer
+	if _, err := io.Copy(io.Discard, none.Body(func() io.Reader { return strings.NewReader("a") })()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProgressOf(t *testing.T) {
+	p := ProgressFunc(func(ProgressEvent) {})
+	var up Upload = &progressUpload{}
+	if ProgressOf(WithProgress(up, p)) == nil {
+		t.Fatal("progress should be attached")
+	}
+	if ProgressOf(up) != nil {
+		t.Fatal("no progress attached")
+	}
+}
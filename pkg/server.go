@@ -0,0 +1,127 @@
+package pkg
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// headers of a request are read within it, so that idle connections cannot hold the server
+const readHeaderTimeout = time.Minute
+
+// ServerOptions are shared by webdav, share and web servers
+type ServerOptions struct {
+	Addr string
+	// url prefix the server is mounted under, such as the path behind a reverse proxy
+	Prefix string
+	// cloud dir exposed by the server, whole drive when empty
+	Root string
+	// serve https, by a self-signed certificate generated at startup when cert and key are not given
+	TLS      bool
+	CertFile string
+	KeyFile  string
+	// timeouts of http.Server, 0 means no timeout
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+}
+
+// Secure reports whether the server serves https
+func (o *ServerOptions) Secure() bool {
+	return o.TLS || o.CertFile != ""
+}
+
+// URL returns base url of the server
+func (o *ServerOptions) URL() string {
+	scheme := "http"
+	if o.Secure() {
+		scheme = "https"
+	}
+	host, port, err := net.SplitHostPort(o.Addr)
+	if err != nil {
+		host, port = o.Addr, ""
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	if port != "" {
+		host = net.JoinHostPort(host, port)
+	}
+	return scheme + "://" + host + strings.TrimSuffix(o.Prefix, "/")
+}
+
+func (o *ServerOptions) ListenAndServe(handler http.Handler) error {
+	server := &http.Server{
+		Addr:              o.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       o.ReadTimeout,
+		WriteTimeout:      o.WriteTimeout,
+		IdleTimeout:       o.IdleTimeout,
+	}
+	if !o.Secure() {
+		return server.ListenAndServe()
+	}
+	if o.CertFile != "" || o.KeyFile != "" {
+		return server.ListenAndServeTLS(o.CertFile, o.KeyFile)
+	}
+	cert, err := selfSigned(o.Addr)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(cert.Certificate[0])
+	log.Printf("self-signed certificate generated, sha256 fingerprint %X", sum)
+	server.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	return server.ListenAndServeTLS("", "")
+}
+
+// selfSigned generates certificate of localhost, host name and the listening ip
+func selfSigned(addr string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"cloud189"}, CommonName: "localhost"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	if name, err := os.Hostname(); err == nil {
+		template.DNSNames = append(template.DNSNames, name)
+	}
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		if ip := net.ParseIP(host); ip != nil && !ip.IsUnspecified() {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else if ip == nil && host != "" {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("generate certificate: %w", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
@@ -0,0 +1,39 @@
+package pkg
+
+import (
+	"crypto/x509"
+	"testing"
+)
+
+func TestServerURL(t *testing.T) {
+	tests := []struct {
+		opts ServerOptions
+		url  string
+	}{
+		{ServerOptions{Addr: ":8080"}, "http://localhost:8080"},
+		{ServerOptions{Addr: "0.0.0.0:80", Prefix: "/dav/"}, "http://localhost:80/dav"},
+		{ServerOptions{Addr: "10.0.0.1:8443", TLS: true}, "https://10.0.0.1:8443"},
+		{ServerOptions{Addr: ":443", CertFile: "cert.pem", Prefix: "/cloud"}, "https://localhost:443/cloud"},
+	}
+	for _, test := range tests {
+		if url := test.opts.URL(); url != test.url {
+			t.Errorf("url %s, want %s", url, test.url)
+		}
+	}
+}
+
+func TestSelfSigned(t *testing.T) {
+	cert, err := selfSigned("10.0.0.1:8443")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, host := range []string{"localhost", "127.0.0.1", "10.0.0.1"} {
+		if err = c.VerifyHostname(host); err != nil {
+			t.Errorf("verify %s: %v", host, err)
+		}
+	}
+}
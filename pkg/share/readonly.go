@@ -0,0 +1,39 @@
+package share
+
+import (
+	"errors"
+
+	"github.com/gowsp/cloud189/pkg"
+)
+
+var errReadOnly = errors.New("share link is read only")
+
+func (c *api) QrLogin() error                           { return errReadOnly }
+func (c *api) PwdLogin(username, password string) error { return errReadOnly }
+func (c *api) Logout() error                            { return errReadOnly }
+func (c *api) Uploader() pkg.ReadWriter                 { return c }
+func (c *api) Write(info pkg.Upload) error              { return errReadOnly }
+func (c *api) Sign() error                              { return errReadOnly }
+func (c *api) Space() (pkg.Space, error)                { return pkg.Space{}, errReadOnly }
+
+func (c *api) Mkdir(parent pkg.File, name string) (pkg.File, error)     { return nil, errReadOnly }
+func (c *api) Rename(target pkg.File, name string) error                { return errReadOnly }
+func (c *api) Move(target pkg.File, source ...pkg.File) (string, error) { return "", errReadOnly }
+func (c *api) Copy(target pkg.File, source ...pkg.File) (string, error) { return "", errReadOnly }
+func (c *api) Delete(file ...pkg.File) (string, error)                  { return "", errReadOnly }
+func (c *api) CheckTask(id string) (*pkg.TaskInfo, error)               { return nil, errReadOnly }
+
+func (c *api) ListRecycle() ([]pkg.File, error)     { return nil, errReadOnly }
+func (c *api) Restore(file ...pkg.File) error       { return errReadOnly }
+func (c *api) DeleteRecycle(file ...pkg.File) error { return errReadOnly }
+func (c *api) EmptyRecycle() error                  { return errReadOnly }
+func (c *api) ListShare() ([]pkg.ShareLink, error)  { return nil, errReadOnly }
+func (c *api) CancelShare(id ...string) error       { return errReadOnly }
+
+func (c *api) CreateShare(file pkg.File, expire int, withCode bool) (*pkg.ShareLink, error) {
+	return nil, errReadOnly
+}
+
+func (c *api) SaveShare(share *pkg.ShareInfo, target pkg.File, file ...pkg.File) error {
+	return errReadOnly
+}
@@ -0,0 +1,38 @@
+package share
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gowsp/cloud189/pkg"
+)
+
+func (c *api) Download(file pkg.File, start int64) (*http.Response, error) {
+	if file.IsDir() {
+		return nil, errors.New("not support download dir")
+	}
+	params := make(url.Values)
+	params.Set("fileId", file.Id())
+	params.Set("shareId", c.info.Id)
+	params.Set("dt", "1")
+	var resp struct {
+		pkg.Result
+		Url string `json:"fileDownloadUrl"`
+	}
+	if err := c.invoker.Get("/open/file/getFileDownloadUrl.action", params, &resp); err != nil {
+		return nil, err
+	}
+	if err := resp.Err("/open/file/getFileDownloadUrl.action"); err != nil {
+		return nil, err
+	}
+	return c.invoker.Open(func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodGet, resp.Url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", start))
+		return req, nil
+	})
+}
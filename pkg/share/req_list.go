@@ -0,0 +1,97 @@
+package share
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+
+	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/file"
+)
+
+type listResp struct {
+	pkg.Result
+	Data struct {
+		Count   int              `json:"count"`
+		Files   []*file.FileInfo `json:"fileList"`
+		Folders []*file.FileInfo `json:"folderList"`
+	} `json:"fileListAO"`
+}
+
+func (c *api) List(parent pkg.File, fileType pkg.FileType) ([]pkg.File, error) {
+	var files []*file.FileInfo
+	if parent.Id() == c.root.Id() && !c.info.IsDir {
+		files = []*file.FileInfo{{
+			ParentId: c.root.FileId,
+			FileId:   json.Number(c.info.FileId),
+			FileName: c.info.Name,
+			FileSize: c.info.Size,
+		}}
+	} else {
+		var err error
+		if files, err = c.listDir(parent.Id(), 1); err != nil {
+			return nil, err
+		}
+	}
+	result := make([]pkg.File, 0, len(files))
+	for _, f := range files {
+		switch {
+		case fileType == pkg.DIR && !f.IsDir():
+		case fileType == pkg.FILE && f.IsDir():
+		default:
+			result = append(result, f)
+		}
+	}
+	return result, nil
+}
+
+func (c *api) Search(parent pkg.File, fileType pkg.FileType, name string) ([]pkg.File, error) {
+	files, err := c.List(parent, fileType)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]pkg.File, 0, 1)
+	for _, f := range files {
+		if f.Name() == name {
+			result = append(result, f)
+		}
+	}
+	return result, nil
+}
+
+func (c *api) listDir(id string, page int) (result []*file.FileInfo, err error) {
+	params := make(url.Values)
+	params.Set("pageNum", strconv.Itoa(page))
+	params.Set("pageSize", "100")
+	params.Set("fileId", id)
+	params.Set("shareDirFileId", id)
+	params.Set("isFolder", "true")
+	params.Set("shareId", c.info.Id)
+	params.Set("shareMode", c.info.Mode)
+	params.Set("accessCode", c.info.AccessCode)
+	params.Set("iconOption", "5")
+	params.Set("orderBy", "lastOpTime")
+	params.Set("descending", "true")
+	var resp listResp
+	if err = c.invoker.Get("/open/share/listShareDir.action", params, &resp); err != nil {
+		return
+	}
+	if err = resp.Err("/open/share/listShareDir.action"); err != nil {
+		return
+	}
+	for _, f := range resp.Data.Folders {
+		f.IsFolder = true
+		f.ParentId = json.Number(id)
+	}
+	for _, f := range resp.Data.Files {
+		f.ParentId = json.Number(id)
+	}
+	result = append(result, resp.Data.Folders...)
+	result = append(result, resp.Data.Files...)
+	if 100*page < resp.Data.Count {
+		var more []*file.FileInfo
+		more, err = c.listDir(id, page+1)
+		result = append(result, more...)
+	}
+	return
+}
@@ -0,0 +1,147 @@
+package share
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/drive"
+	"github.com/gowsp/cloud189/pkg/file"
+	"github.com/gowsp/cloud189/pkg/invoker"
+)
+
+// Scheme prefixes a share path, share://{code}/{path in share}
+const Scheme = "share://"
+
+var (
+	codeExp   = regexp.MustCompile(`(?:/t/|[?&]code=)([0-9A-Za-z]+)`)
+	rawExp    = regexp.MustCompile(`^[0-9A-Za-z]+$`)
+	accessExp = regexp.MustCompile(`(?:访问码|提取码|accessCode=)[:：\s]*([0-9A-Za-z]{4})`)
+)
+
+// ParseURL extracts share code and access code from share url,
+// supports https://cloud.189.cn/t/{code}, https://cloud.189.cn/web/share?code={code},
+// the h5 share page and the raw share code
+func ParseURL(link string) (code, accessCode string, err error) {
+	if m := accessExp.FindStringSubmatch(link); m != nil {
+		accessCode = m[1]
+	}
+	if m := codeExp.FindStringSubmatch(link); m != nil {
+		return m[1], accessCode, nil
+	}
+	if rawExp.MatchString(link) {
+		return link, accessCode, nil
+	}
+	return "", "", fmt.Errorf("invalid share url %s", link)
+}
+
+// IsShare reports whether name is a share path
+func IsShare(name string) bool {
+	return strings.HasPrefix(name, Scheme)
+}
+
+// Split splits share path into share code and the path in share
+func Split(name string) (code, file string) {
+	code, file, _ = strings.Cut(strings.TrimPrefix(name, Scheme), "/")
+	return code, path.Join("/", file)
+}
+
+type shareResp struct {
+	pkg.Result
+	ShareId        json.Number `json:"shareId"`
+	ShareMode      json.Number `json:"shareMode"`
+	FileId         json.Number `json:"fileId"`
+	FileName       string      `json:"fileName"`
+	FileSize       int64       `json:"fileSize"`
+	IsFolder       bool        `json:"isFolder"`
+	NeedAccessCode int         `json:"needAccessCode"`
+}
+
+type api struct {
+	invoker *invoker.Invoker
+	info    *pkg.ShareInfo
+	root    *file.FileInfo
+}
+
+// Open resolves the share link and returns a read only drive rooted at the share,
+// access code in the link text is used when accessCode is empty
+func Open(link, accessCode string) (pkg.Drive, *pkg.ShareInfo, error) {
+	code, parsed, err := ParseURL(link)
+	if err != nil {
+		return nil, nil, err
+	}
+	if accessCode == "" {
+		accessCode = parsed
+	}
+	api := &api{}
+	api.invoker = invoker.NewInvoker("https://cloud.189.cn/api", api.refresh, &invoker.Config{})
+	if err = api.resolve(code, accessCode); err != nil {
+		return nil, nil, err
+	}
+	return drive.NewWithRoot(api, api.root), api.info, nil
+}
+
+func (c *api) refresh() error {
+	return fmt.Errorf("share link does not exist or has expired: %w", pkg.ErrNotFound)
+}
+
+func (c *api) resolve(code, accessCode string) error {
+	var resp shareResp
+	err := c.invoker.Get("/open/share/getShareInfoByCodeV2.action", url.Values{"shareCode": {code}}, &resp)
+	if err != nil {
+		return err
+	}
+	if err = resp.Err("/open/share/getShareInfoByCodeV2.action"); err != nil {
+		return err
+	}
+	shareId := resp.ShareId.String()
+	if resp.NeedAccessCode == 1 {
+		if accessCode == "" {
+			return errors.New("share link requires an access code")
+		}
+		if shareId, err = c.checkAccessCode(code, accessCode); err != nil {
+			return err
+		}
+	}
+	c.info = &pkg.ShareInfo{
+		Id:         shareId,
+		Code:       code,
+		AccessCode: accessCode,
+		Mode:       resp.ShareMode.String(),
+		FileId:     resp.FileId.String(),
+		Name:       resp.FileName,
+		Size:       resp.FileSize,
+		IsDir:      resp.IsFolder,
+	}
+	c.root = &file.FileInfo{IsFolder: true, FileId: resp.FileId, FileName: resp.FileName}
+	if !resp.IsFolder {
+		// a single file share is presented as a dir containing the file
+		c.root.FileId = json.Number("share-" + shareId)
+	}
+	return nil
+}
+
+func (c *api) checkAccessCode(code, accessCode string) (string, error) {
+	params := make(url.Values)
+	params.Set("shareCode", code)
+	params.Set("accessCode", accessCode)
+	var resp struct {
+		pkg.Result
+		ShareId json.Number `json:"shareId"`
+	}
+	if err := c.invoker.Get("/open/share/checkAccessCode.action", params, &resp); err != nil {
+		return "", err
+	}
+	if err := resp.Err("/open/share/checkAccessCode.action"); err != nil {
+		return "", err
+	}
+	if resp.ShareId == "" {
+		return "", errors.New("invalid access code")
+	}
+	return resp.ShareId.String(), nil
+}
@@ -0,0 +1,40 @@
+package share
+
+import "testing"
+
+func TestParseURL(t *testing.T) {
+	cases := []struct {
+		link, code, access string
+	}{
+		{"https://cloud.189.cn/t/AbCd1234", "AbCd1234", ""},
+		{"https://cloud.189.cn/t/AbCd1234（访问码：x1y2）", "AbCd1234", "x1y2"},
+		{"https://cloud.189.cn/web/share?code=AbCd1234", "AbCd1234", ""},
+		{"https://h5.cloud.189.cn/share.html#/t/AbCd1234", "AbCd1234", ""},
+		{"AbCd1234", "AbCd1234", ""},
+	}
+	for _, c := range cases {
+		code, access, err := ParseURL(c.link)
+		if err != nil || code != c.code || access != c.access {
+			t.Errorf("ParseURL(%q) = %q, %q, %v", c.link, code, access, err)
+		}
+	}
+	if _, _, err := ParseURL("https://example.com/"); err == nil {
+		t.Error("expect error for invalid url")
+	}
+}
+
+func TestSplit(t *testing.T) {
+	cases := []struct {
+		name, code, file string
+	}{
+		{"share://AbCd1234", "AbCd1234", "/"},
+		{"share://AbCd1234/", "AbCd1234", "/"},
+		{"share://AbCd1234/dir/a.txt", "AbCd1234", "/dir/a.txt"},
+	}
+	for _, c := range cases {
+		code, file := Split(c.name)
+		if code != c.code || file != c.file {
+			t.Errorf("Split(%q) = %q, %q", c.name, code, file)
+		}
+	}
+}
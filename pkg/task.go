@@ -0,0 +1,122 @@
+package pkg
+
+import (
+	"fmt"
+	"time"
+)
+
+type TaskStatus int
+
+// status of server side batch task
+const (
+	TaskInit TaskStatus = iota + 1
+	TaskConflict
+	TaskRunning
+	TaskDone
+)
+
+func (s TaskStatus) String() string {
+	switch s {
+	case TaskInit:
+		return "init"
+	case TaskConflict:
+		return "conflict"
+	case TaskRunning:
+		return "running"
+	case TaskDone:
+		return "done"
+	}
+	return fmt.Sprintf("unknown(%d)", int(s))
+}
+
+// result of a file in batch task
+const (
+	TaskFileDone     = "done"
+	TaskFileFailed   = "failed"
+	TaskFileConflict = "conflict"
+)
+
+type TaskFile struct {
+	Id     string `json:"id"`
+	Name   string `json:"name"`
+	IsDir  bool   `json:"isDir"`
+	Result string `json:"result,omitempty"`
+}
+
+type TaskInfo struct {
+	Id     string     `json:"id"`
+	Status TaskStatus `json:"status"`
+	// percent of progress
+	Process   int    `json:"process"`
+	Total     int    `json:"total"`
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+	Skipped   int    `json:"skipped"`
+	Error     string `json:"error,omitempty"`
+	// id of files done
+	SucceededIds []string   `json:"-"`
+	Conflicts    []TaskFile `json:"conflicts,omitempty"`
+	// per file result, filled when the task is waited
+	Files []TaskFile `json:"files,omitempty"`
+}
+
+func (t *TaskInfo) String() string {
+	return fmt.Sprintf("task %s %s %d%%, total %d, succeeded %d, failed %d, skipped %d",
+		t.Id, t.Status, t.Process, t.Total, t.Succeeded, t.Failed, t.Skipped)
+}
+
+// ConflictPolicy decides what to do with a same-named item in the target dir
+type ConflictPolicy string
+
+const (
+	ConflictFail      ConflictPolicy = "fail"
+	ConflictSkip      ConflictPolicy = "skip"
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	ConflictRename    ConflictPolicy = "rename"
+	// overwrite when the uploading file is newer, for upload only
+	ConflictNewer ConflictPolicy = "newer"
+)
+
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(s); p {
+	case ConflictFail, ConflictSkip, ConflictOverwrite, ConflictRename:
+		return p, nil
+	case "":
+		return ConflictFail, nil
+	}
+	return "", fmt.Errorf("invalid conflict policy %s, expect skip, overwrite, rename or fail", s)
+}
+
+// ParseUploadPolicy parses policy of uploading file whose name exists,
+// empty means the server default
+func ParseUploadPolicy(s string) (ConflictPolicy, error) {
+	if s == "" || ConflictPolicy(s) == ConflictNewer {
+		return ConflictPolicy(s), nil
+	}
+	return ParseConflictPolicy(s)
+}
+
+type TaskConfig struct {
+	// return once the task is submitted
+	Async bool
+	// policy of same-named item in target dir, fail when empty
+	Conflict ConflictPolicy
+	// max time to wait for the task, 10 minutes when zero
+	Timeout time.Duration
+	// called when the task is submitted and on every polled status
+	Progress func(TaskInfo)
+}
+
+func (c *TaskConfig) Policy() ConflictPolicy {
+	if c.Conflict == "" {
+		return ConflictFail
+	}
+	return c.Conflict
+}
+
+func (c *TaskConfig) WaitTimeout() time.Duration {
+	if c.Timeout <= 0 {
+		return 10 * time.Minute
+	}
+	return c.Timeout
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/gowsp/cloud189/pkg/util"
 )
@@ -38,6 +39,15 @@ type Uploader1 interface {
 type UploadConfig struct {
 	Num    uint32
 	Parten string
+	// policy of same-named file in cloud dir, empty means the server default
+	Conflict ConflictPolicy
+	// receives progress of each file, optional
+	Progress Progress
+}
+
+type DownloadConfig struct {
+	// receives progress of each file, optional
+	Progress Progress
 }
 
 func (c *UploadConfig) NewTask() *util.TaskPool {
@@ -50,3 +60,20 @@ func (c *UploadConfig) Check() (err error) {
 	c.Parten = strings.TrimSpace(c.Parten)
 	return nil
 }
+
+// WithModTime attaches modification time to upload whose data has none, such as request bodies,
+// it is compared with the cloud file by newer policy
+func WithModTime(up Upload, t time.Time) Upload {
+	if t.IsZero() {
+		return up
+	}
+	return &modTimeUpload{Upload: up, modTime: t}
+}
+
+type modTimeUpload struct {
+	Upload
+	modTime time.Time
+}
+
+func (u *modTimeUpload) ModTime() time.Time { return u.modTime }
+func (u *modTimeUpload) Unwrap() Upload     { return u.Upload }
@@ -0,0 +1,180 @@
+package util
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// max bytes read at once by limited reader, keeps the rate smooth
+const limitChunk = 32 * 1024
+
+type limitSlot struct {
+	// minutes since midnight
+	at   int
+	rate float64
+}
+
+// Limiter is a token bucket shared by readers, rate is bytes per second and 0 means unlimited
+type Limiter struct {
+	mu       sync.Mutex
+	spec     string
+	schedule []limitSlot
+	tokens   float64
+	last     time.Time
+}
+
+func NewLimiter() *Limiter {
+	return &Limiter{spec: "off"}
+}
+
+// Set changes the limit, spec is a rate such as 2M, 512K or off,
+// or a daily schedule such as "08:00,1M 20:00,off"
+func (l *Limiter) Set(spec string) error {
+	schedule, err := parseSchedule(spec)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.spec = strings.TrimSpace(spec)
+	l.schedule = schedule
+	l.tokens, l.last = 0, time.Time{}
+	return nil
+}
+
+func (l *Limiter) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.spec
+}
+
+// Rate returns bytes per second in effect at now
+func (l *Limiter) Rate(now time.Time) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate(now)
+}
+
+func (l *Limiter) rate(now time.Time) float64 {
+	if len(l.schedule) == 0 {
+		return 0
+	}
+	minute := now.Hour()*60 + now.Minute()
+	// the last slot of the day is in effect before the first one
+	current := l.schedule[len(l.schedule)-1]
+	for _, slot := range l.schedule {
+		if slot.at > minute {
+			break
+		}
+		current = slot
+	}
+	return current.rate
+}
+
+// wait takes n bytes from the bucket, sleeps until they are available
+func (l *Limiter) wait(n int) {
+	l.mu.Lock()
+	now := time.Now()
+	rate := l.rate(now)
+	if rate <= 0 {
+		l.mu.Unlock()
+		return
+	}
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * rate
+	}
+	// burst of one second
+	if l.tokens > rate {
+		l.tokens = rate
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+	time.Sleep(delay)
+}
+
+// Reader limits reading of r, r is returned as is when l is nil
+func (l *Limiter) Reader(r io.Reader) io.Reader {
+	if l == nil {
+		return r
+	}
+	return &limitReader{r: r, l: l}
+}
+
+type limitReader struct {
+	r io.Reader
+	l *Limiter
+}
+
+func (r *limitReader) Read(p []byte) (int, error) {
+	if len(p) > limitChunk && r.l.Rate(time.Now()) > 0 {
+		p = p[:limitChunk]
+	}
+	n, err := r.r.Read(p)
+	r.l.wait(n)
+	return n, err
+}
+
+func parseSchedule(spec string) ([]limitSlot, error) {
+	spec = strings.TrimSpace(spec)
+	if !strings.Contains(spec, ",") {
+		rate, err := ParseRate(spec)
+		if err != nil || rate == 0 {
+			return nil, err
+		}
+		return []limitSlot{{rate: rate}}, nil
+	}
+	var schedule []limitSlot
+	for _, item := range strings.Fields(spec) {
+		at, value, ok := strings.Cut(item, ",")
+		if !ok {
+			return nil, fmt.Errorf("invalid bandwidth schedule %s, example: 08:00,1M 20:00,off", item)
+		}
+		t, err := time.Parse("15:04", at)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time %s in bandwidth schedule", at)
+		}
+		rate, err := ParseRate(value)
+		if err != nil {
+			return nil, err
+		}
+		schedule = append(schedule, limitSlot{at: t.Hour()*60 + t.Minute(), rate: rate})
+	}
+	sort.Slice(schedule, func(i, j int) bool { return schedule[i].at < schedule[j].at })
+	return schedule, nil
+}
+
+// ParseRate parses bytes per second such as 512K, 2M, 1.5G or off
+func ParseRate(raw string) (float64, error) {
+	s := strings.ToUpper(strings.TrimSpace(raw))
+	if s == "" || s == "OFF" || s == "0" {
+		return 0, nil
+	}
+	s = strings.TrimSuffix(s, "B")
+	unit := 1.0
+	switch {
+	case strings.HasSuffix(s, "K"):
+		unit = 1 << 10
+	case strings.HasSuffix(s, "M"):
+		unit = 1 << 20
+	case strings.HasSuffix(s, "G"):
+		unit = 1 << 30
+	}
+	if unit > 1 {
+		s = s[:len(s)-1]
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid bandwidth %s, example: 512K, 2M or off", raw)
+	}
+	return value * unit, nil
+}
@@ -0,0 +1,73 @@
+package util
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := map[string]float64{
+		"off":  0,
+		"":     0,
+		"2048": 2048,
+		"512K": 512 << 10,
+		"2M":   2 << 20,
+		"1.5m": 1.5 * (1 << 20),
+		"1GB":  1 << 30,
+	}
+	for spec, want := range tests {
+		got, err := ParseRate(spec)
+		if err != nil || got != want {
+			t.Fatalf("%s: got %v %v, want %v", spec, got, err, want)
+		}
+	}
+	if _, err := ParseRate("fast"); err == nil {
+		t.Fatal("invalid rate should fail")
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	l := NewLimiter()
+	if err := l.Set("08:00,1M 20:00,off"); err != nil {
+		t.Fatal(err)
+	}
+	day := func(hour, minute int) time.Time {
+		return time.Date(2024, 1, 1, hour, minute, 0, 0, time.Local)
+	}
+	if rate := l.Rate(day(9, 0)); rate != 1<<20 {
+		t.Fatalf("rate at 09:00 is %v", rate)
+	}
+	if rate := l.Rate(day(21, 0)); rate != 0 {
+		t.Fatalf("rate at 21:00 is %v", rate)
+	}
+	// before the first slot the last one of the day is in effect
+	if rate := l.Rate(day(7, 59)); rate != 0 {
+		t.Fatalf("rate at 07:59 is %v", rate)
+	}
+	if err := l.Set("08:00"); err == nil {
+		t.Fatal("08:00 without rate should fail")
+	}
+}
+
+func TestLimitReader(t *testing.T) {
+	l := NewLimiter()
+	if err := l.Set("100K"); err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, 150<<10)
+	start := time.Now()
+	n, err := io.Copy(io.Discard, l.Reader(bytes.NewReader(data)))
+	if err != nil || n != int64(len(data)) {
+		t.Fatal(n, err)
+	}
+	// the bucket starts empty, 150K at 100K/s takes a second and a half
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("read too fast: %s", elapsed)
+	}
+	var none *Limiter
+	if r := bytes.NewReader(data); none.Reader(r) != r {
+		t.Fatal("nil limiter should not wrap")
+	}
+}
@@ -1,8 +1,9 @@
 package web
 
 import (
-	"errors"
+	"sync"
 
+	"github.com/gowsp/cloud189/pkg"
 	"github.com/gowsp/cloud189/pkg/invoker"
 	"github.com/gowsp/cloud189/pkg/util"
 )
@@ -70,8 +71,14 @@ type api struct {
 	invoker    *invoker.Invoker
 	sessionKey string
 	conf       *invoker.Config
+	// upload id -> *pkg.Transfer of uploading files
+	transfers sync.Map
+	// task id -> taskType of submitted tasks, needed to check them
+	tasks sync.Map
 }
 
+var _ pkg.DriveApi = (*api)(nil)
+
 func NewApi(path string) *api {
 	conf, _ := invoker.OpenConfig(path)
 	api := &api{conf: conf}
@@ -96,7 +103,7 @@ func (i *api) login(user *invoker.User) error {
 		return err
 	}
 	defer resp.Body.Close()
-	i.conf.User = user
+	i.conf.SetUser(user.Name, user.Password)
 	i.conf.SSON = result.SSON
 	i.conf.Auth = i.invoker.Cookie("https://cloud.189.cn", "COOKIE_LOGIN_USER")
 	return i.conf.Save()
@@ -115,7 +122,11 @@ func (i *api) refresh() error {
 		return nil
 	}
 	if i.conf.User == nil {
-		return errors.New("no user info in config, please login first")
+		return pkg.ErrSessionExpired
+	}
+	password, err := i.conf.Password()
+	if err != nil {
+		return err
 	}
-	return i.Login(i.conf.User.Name, i.conf.User.Password)
+	return i.Login(i.conf.User.Name, password)
 }
@@ -4,24 +4,36 @@ import (
 	"encoding/json"
 	"net/url"
 	"path"
+	"strings"
 
+	"github.com/gowsp/cloud189/pkg"
 	"github.com/gowsp/cloud189/pkg/cache"
 )
 
-
-
 type mkdirs struct {
 	ParentId string   `json:"parentId,omitempty"`
 	Paths    []string `json:"paths,omitempty"`
 }
 
-
-func (c *api) Mkdir(parentId, path string, parents bool) error {
-	if parents {
-		_, err := c.Mkdirs(parentId, path)
-		return err
+// Mkdir creates dir name in parent, name may have parent dirs to create such as a/b
+func (c *api) Mkdir(parent pkg.File, name string) (pkg.File, error) {
+	name = strings.Trim(name, "/")
+	var err error
+	if strings.Contains(name, "/") {
+		_, err = c.Mkdirs(parent.Id(), name)
+	} else {
+		err = c.mkdir(parent.Id(), name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	dir := parent
+	for _, v := range strings.Split(name, "/") {
+		if dir, err = c.FindDir(dir.Id(), v); err != nil {
+			return nil, err
+		}
 	}
-	return c.mkdir(parentId, path)
+	return dir, nil
 }
 func (c *api) Mkdirs(parentId string, dirs ...string) (map[string]interface{}, error) {
 	length := len(dirs)
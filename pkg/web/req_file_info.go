@@ -8,11 +8,10 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"path"
-	"strconv"
 	"time"
 
 	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/file"
 )
 
 type detail struct {
@@ -42,10 +41,10 @@ func (f *detail) Sys() interface{} {
 	}
 }
 func (f *detail) ContentType(ctx context.Context) (string, error) {
-	return path.Ext(f.Name()), nil
+	return file.ContentType(f.Name()), nil
 }
 func (f *detail) ETag(ctx context.Context) (string, error) {
-	return strconv.FormatInt(f.FileModTime, 10), nil
+	return file.ETag(f), nil
 }
 
 func (c *api) Detail(id string) (pkg.File, error) {
@@ -58,11 +57,16 @@ func (c *api) Download(file pkg.File, start int64) (*http.Response, error) {
 	if file.IsDir() {
 		return nil, errors.New("not support download dir")
 	}
-	file, _ = c.Detail(file.Id())
-	req, err := http.NewRequest(http.MethodGet, file.Sys().(pkg.FileExt).DownloadUrl, nil)
+	file, err := c.Detail(file.Id())
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, file.Size()))
-	return c.invoker.Send(req)
+	return c.invoker.Open(func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodGet, file.Sys().(pkg.FileExt).DownloadUrl, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, file.Size()))
+		return req, nil
+	})
 }
@@ -18,6 +18,28 @@ type listResp struct {
 	Data  []*detail   `json:"data,omitempty"`
 }
 
+func (c *api) List(parent pkg.File, fileType pkg.FileType) ([]pkg.File, error) {
+	files, err := c.ListFile(parent.Id())
+	if err != nil {
+		return nil, err
+	}
+	return filterType(files, fileType), nil
+}
+
+// filterType keeps files of fileType
+func filterType(files []pkg.File, fileType pkg.FileType) []pkg.File {
+	if fileType == pkg.ALL {
+		return files
+	}
+	result := make([]pkg.File, 0, len(files))
+	for _, f := range files {
+		if f.IsDir() == (fileType == pkg.DIR) {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
 func (c *api) ListFile(id string) ([]pkg.File, error) {
 	return cache.List(id, func() ([]*file.FileInfo, error) { return c.openList(id, 1) })
 }
@@ -72,6 +94,10 @@ type fileTime time.Time
 
 func (j *fileTime) UnmarshalJSON(b []byte) error {
 	json := string(b)
+	if ms, err := strconv.ParseInt(json, 10, 64); err == nil {
+		*j = fileTime(time.UnixMilli(ms))
+		return nil
+	}
 	s := strings.Trim(json, "\"")
 	t, err := time.Parse("2006-01-02 15:04:05", s)
 	if err != nil {
@@ -3,36 +3,105 @@ package web
 import (
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/gowsp/cloud189/pkg"
 	"github.com/gowsp/cloud189/pkg/cache"
 	"github.com/gowsp/cloud189/pkg/file"
+	"github.com/gowsp/cloud189/pkg/invoker"
 )
 
+func (client *api) Uploader() pkg.ReadWriter {
+	return client
+}
+
+// Write uploads parts of upload in order by the web upload api
+func (client *api) Write(upload pkg.Upload) error {
+	f := &webUpload{Upload: upload}
+	count := max(upload.SliceNum(), 1)
+	for i := 0; i < count; i++ {
+		f.complete = i == count-1
+		if err := client.Upload(f, upload.Part(int64(i))); err != nil {
+			return err
+		}
+		if f.IsExists() {
+			return nil
+		}
+	}
+	return nil
+}
+
+// webUpload keeps state of the web upload api for an upload
+type webUpload struct {
+	pkg.Upload
+	once     sync.Once
+	id       string
+	exists   bool
+	complete bool
+}
+
+func (u *webUpload) Prepare(init func())   { u.once.Do(init) }
+func (u *webUpload) IsExists() bool        { return u.exists }
+func (u *webUpload) Type() string          { return "" }
+func (u *webUpload) IsComplete() bool      { return u.complete }
+func (u *webUpload) UploadId() string      { return u.id }
+func (u *webUpload) SetExists(exists bool) { u.exists = exists }
+func (u *webUpload) SetUploadId(id string) { u.id = id }
+func (u *webUpload) Unwrap() pkg.Upload    { return u.Upload }
+
 func (client *api) Upload(upload pkg.UploadFile, part pkg.UploadPart) error {
+	var err error
 	upload.Prepare(func() {
-		client.init(upload, upload.ParentId())
+		err = client.init(upload, upload.ParentId())
 	})
+	if err != nil {
+		return err
+	}
+	t := client.transfer(upload)
 	if upload.IsExists() {
 		fmt.Println("file exists, fast upload")
 		client.commit(upload, upload.UploadId(), "0")
+		client.finish(upload, nil)
 		return nil
 	}
-	err := client.UploadPart(part, upload.UploadId())
+	err = client.UploadPart(t, part, upload.UploadId())
 	if err != nil {
+		client.finish(upload, err)
 		return err
 	}
 	if upload.IsComplete() {
 		client.commit(upload, upload.UploadId(), "1")
+		client.finish(upload, nil)
 	}
 	return nil
 }
 
+// transfer returns progress tracker of upload, which lives across its parts
+func (client *api) transfer(upload pkg.UploadFile) *pkg.Transfer {
+	if v, ok := client.transfers.Load(upload.UploadId()); ok {
+		return v.(*pkg.Transfer)
+	}
+	t := pkg.NewTransfer(pkg.ProgressOf(upload), pkg.OpUpload, upload.Name(), upload.Size(), upload.SliceNum())
+	if t == nil {
+		return nil
+	}
+	v, loaded := client.transfers.LoadOrStore(upload.UploadId(), t)
+	if !loaded {
+		t.Start(0)
+	}
+	return v.(*pkg.Transfer)
+}
+
+func (client *api) finish(upload pkg.UploadFile, err error) {
+	if v, ok := client.transfers.LoadAndDelete(upload.UploadId()); ok {
+		v.(*pkg.Transfer).Finish(err)
+	}
+}
+
 type uploadInfo struct {
 	UploadType     int    `json:"uploadType,omitempty"`
 	UploadHost     string `json:"uploadHost,omitempty"`
@@ -67,7 +136,9 @@ func (c *api) init(i pkg.UploadFile, parentId string) error {
 		params.Set("sliceMd5", i.SliceMD5())
 	}
 	var upload initResp
-	c.do("/person/initMultiUpload", params, &upload)
+	if err := c.do("/person/initMultiUpload", params, &upload); err != nil {
+		return err
+	}
 	fileId := upload.Data.UploadFileId
 	if fileId == "" {
 		return errors.New("error get upload fileid")
@@ -91,7 +162,7 @@ type uploadUrls struct {
 	RequestHeader string `json:"requestHeader,omitempty"`
 }
 
-func (client *api) UploadPart(part pkg.UploadPart, fileId string) error {
+func (client *api) UploadPart(t *pkg.Transfer, part pkg.UploadPart, fileId string) error {
 	p := make(url.Values)
 	num := strconv.Itoa(part.Num() + 1)
 	p.Set("partInfo", fmt.Sprintf("%s-%s", num, part.Name()))
@@ -101,25 +172,28 @@ func (client *api) UploadPart(part pkg.UploadPart, fileId string) error {
 	if err := client.do("/person/getMultiUploadUrls", p, &urlRespData); err != nil {
 		return err
 	}
-	fmt.Printf("start uploading part %s\n", num)
-
 	upload := urlRespData.Data["partNumber_"+num]
-	req, _ := http.NewRequest(http.MethodPut, upload.RequestURL, part.Data())
-	headers := strings.Split(upload.RequestHeader, "&")
-	for _, v := range headers {
-		i := strings.Index(v, "=")
-		req.Header.Set(v[0:i], v[i+1:])
-	}
-	resp, err := client.invoker.Send(req)
+	body, size, err := file.PartBody(part)
 	if err != nil {
 		return err
 	}
-	resp.Body.Close()
-	if resp.StatusCode != 200 {
-		data, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("upload error, %s", string(data))
+	body = t.Body(body)
+	err = client.invoker.SendRetry(func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPut, upload.RequestURL, pkg.Bandwidth.Reader(body()))
+		if err != nil {
+			return nil, err
+		}
+		req.ContentLength = size
+		for _, v := range strings.Split(upload.RequestHeader, "&") {
+			i := strings.Index(v, "=")
+			req.Header.Set(v[0:i], v[i+1:])
+		}
+		return req, nil
+	}, invoker.CheckStatus)
+	if err != nil {
+		return fmt.Errorf("upload part %s: %w", num, err)
 	}
-	fmt.Printf("part %s upload completed\n", num)
+	t.PartDone()
 	return nil
 }
 
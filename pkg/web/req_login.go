@@ -1,6 +1,8 @@
 package web
 
 import (
+	"errors"
+
 	"github.com/gowsp/cloud189/pkg/invoker"
 )
 
@@ -8,3 +10,21 @@ func (c *api) Login(name, password string) error {
 	user := &invoker.User{Name: name, Password: password}
 	return c.login(user)
 }
+
+func (c *api) PwdLogin(username, password string) error {
+	return c.Login(username, password)
+}
+
+func (c *api) QrLogin() error {
+	return errors.New("qr code login is not supported by web api")
+}
+
+// Logout clears session and user of config
+func (c *api) Logout() error {
+	if err := c.conf.ClearUser(); err != nil {
+		return err
+	}
+	c.conf.SSON = ""
+	c.conf.Auth = ""
+	return c.conf.Save()
+}
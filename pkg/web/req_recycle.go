@@ -0,0 +1,58 @@
+package web
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/file"
+)
+
+func (c *api) ListRecycle() ([]pkg.File, error) {
+	files, err := c.listRecycle(1)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]pkg.File, len(files))
+	for i, f := range files {
+		result[i] = f
+	}
+	return result, nil
+}
+
+func (c *api) listRecycle(page int) (result []*file.FileInfo, err error) {
+	params := make(url.Values)
+	params.Set("pageNum", strconv.Itoa(page))
+	params.Set("pageSize", "100")
+	params.Set("iconOption", "5")
+	params.Set("family", "false")
+	var files fileList
+	err = c.invoker.Get("/open/file/listRecycleBinFiles.action", params, &files)
+	if err != nil {
+		return
+	}
+	for _, f := range files.Folders {
+		f.IsFolder = true
+	}
+	result = append(result, files.Folders...)
+	result = append(result, files.Files...)
+	if page*100 < files.Count {
+		var more []*file.FileInfo
+		more, err = c.listRecycle(page + 1)
+		result = append(result, more...)
+	}
+	return
+}
+
+func (c *api) Restore(files ...pkg.File) error {
+	_, err := c.createTask(restore, "", files...)
+	return err
+}
+func (c *api) DeleteRecycle(files ...pkg.File) error {
+	_, err := c.createTask(clearRecycle, "", files...)
+	return err
+}
+func (c *api) EmptyRecycle() error {
+	_, err := c.createTask(emptyRecycle, "")
+	return err
+}
@@ -27,6 +27,18 @@ func (c *api) FindFile(id, name string) (pkg.File, error) {
 	})
 }
 
+func (c *api) Search(parent pkg.File, fileType pkg.FileType, name string) ([]pkg.File, error) {
+	found, err := c.search(parent.Id(), name, 1)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]pkg.File, len(found))
+	for i, f := range found {
+		files[i] = f
+	}
+	return filterType(files, fileType), nil
+}
+
 func (c *api) search(id, name string, page int) (result []*file.FileInfo, err error) {
 	params := make(url.Values)
 	params.Set("folderId", id)
@@ -0,0 +1,12 @@
+package web
+
+import (
+	"net/url"
+
+	"github.com/gowsp/cloud189/pkg"
+)
+
+func (c *api) SaveShare(share *pkg.ShareInfo, target pkg.File, files ...pkg.File) error {
+	_, err := c.submitTask(shareSave, target.Id(), url.Values{"shareId": {share.Id}}, files...)
+	return err
+}
@@ -28,7 +28,7 @@ func (client *api) Sign() error {
 func (a *api) signReq(url string) {
 	var e signResp
 	req, _ := http.NewRequest(http.MethodGet, url, nil)
-	err := a.invoker.Do(req, &e, 3)
+	err := a.invoker.Do(req, &e)
 	if err == nil {
 		switch e.ErrorCode {
 		case "User_Not_Chance":
@@ -12,9 +12,13 @@ import (
 type taskType string
 
 const (
-	copy   taskType = "COPY"
-	move   taskType = "MOVE"
-	delete taskType = "DELETE"
+	copy         taskType = "COPY"
+	move         taskType = "MOVE"
+	delete       taskType = "DELETE"
+	restore      taskType = "RESTORE"
+	clearRecycle taskType = "CLEAR_RECYCLE"
+	emptyRecycle taskType = "EMPTY_RECYCLE"
+	shareSave    taskType = "SHARE_SAVE"
 )
 
 type taskInfo struct {
@@ -32,20 +36,49 @@ func (t *taskInfo) MarshalJSON() ([]byte, error) {
 	return []byte(json), nil
 }
 
-func (c *api) Copy(target string, files ...pkg.File) error {
-	return c.createTask(copy, target, files...)
+type taskResp struct {
+	pkg.Result
+	TaskId string `json:"taskId"`
 }
-func (c *api) Move(target string, files ...pkg.File) error {
-	return c.createTask(move, target, files...)
+
+type taskStatus struct {
+	TaskStatus   int           `json:"taskStatus"`
+	Process      int           `json:"process"`
+	SubTaskCount int           `json:"subTaskCount"`
+	Succeeded    int           `json:"successedCount"`
+	Failed       int           `json:"failedCount"`
+	Skipped      int           `json:"skipCount"`
+	SucceededIds []json.Number `json:"successedFileIdList"`
+	ErrorCode    string        `json:"errorCode"`
+}
+
+type conflictResp struct {
+	TaskInfos []struct {
+		FileId   json.Number `json:"fileId"`
+		FileName string      `json:"fileName"`
+		IsFolder int         `json:"isFolder"`
+	} `json:"taskInfos"`
+}
+
+func (c *api) Copy(target pkg.File, files ...pkg.File) (string, error) {
+	return c.createTask(copy, target.Id(), files...)
 }
-func (c *api) Delete(files ...pkg.File) error {
+func (c *api) Move(target pkg.File, files ...pkg.File) (string, error) {
+	return c.createTask(move, target.Id(), files...)
+}
+func (c *api) Delete(files ...pkg.File) (string, error) {
 	return c.createTask(delete, "", files...)
 }
 
-func (c *api) createTask(taskType taskType, targetFolderId string, files ...pkg.File) error {
+func (c *api) createTask(taskType taskType, targetFolderId string, files ...pkg.File) (string, error) {
+	return c.submitTask(taskType, targetFolderId, nil, files...)
+}
+
+// submitTask creates batch task and returns its id
+func (c *api) submitTask(taskType taskType, targetFolderId string, extra url.Values, files ...pkg.File) (string, error) {
 	length := len(files)
-	if length == 0 {
-		return nil
+	if length == 0 && taskType != emptyRecycle {
+		return "", nil
 	}
 	rm := make([]taskInfo, length)
 	for i, v := range files {
@@ -53,25 +86,76 @@ func (c *api) createTask(taskType taskType, targetFolderId string, files ...pkg.
 	}
 	data, err := json.Marshal(rm)
 	if err != nil {
-		return err
+		return "", err
 	}
 	params := make(url.Values)
+	for k, v := range extra {
+		params[k] = v
+	}
 	params.Set("type", string(taskType))
 	params.Set("taskInfos", string(data))
 	params.Set("targetFolderId", targetFolderId)
-	var result map[string]interface{}
+	var result taskResp
 	err = c.invoker.Post("/open/batch/createBatchTask.action", params, &result)
 	if err != nil {
-		return err
+		return "", err
+	}
+	if err = result.Err("/open/batch/createBatchTask.action"); err != nil {
+		return "", err
 	}
 	switch taskType {
-	case copy:
+	case copy, shareSave:
 		cache.InvalidId(targetFolderId)
 	case move:
 		cache.Invalid(files...)
 		cache.InvalidId(targetFolderId)
 	case delete:
 		cache.Delete(files...)
+	case restore:
+		cache.Invalid(files...)
+	}
+	c.tasks.Store(result.TaskId, taskType)
+	return result.TaskId, nil
+}
+
+func (c *api) CheckTask(id string) (*pkg.TaskInfo, error) {
+	params := url.Values{"taskId": {id}}
+	if t, ok := c.tasks.Load(id); ok {
+		params.Set("type", string(t.(taskType)))
+	}
+	var result taskStatus
+	if err := c.invoker.Post("/open/batch/checkBatchTask.action", params, &result); err != nil {
+		return nil, err
+	}
+	info := &pkg.TaskInfo{
+		Id:        id,
+		Status:    pkg.TaskStatus(result.TaskStatus),
+		Process:   result.Process,
+		Total:     result.SubTaskCount,
+		Succeeded: result.Succeeded,
+		Failed:    result.Failed,
+		Skipped:   result.Skipped,
+		Error:     result.ErrorCode,
+	}
+	if info.Status == pkg.TaskDone {
+		c.tasks.Delete(id)
+	}
+	for _, id := range result.SucceededIds {
+		info.SucceededIds = append(info.SucceededIds, id.String())
+	}
+	if info.Status == pkg.TaskConflict {
+		var conflict conflictResp
+		if err := c.invoker.Post("/open/batch/getConflictTaskInfo.action", params, &conflict); err != nil {
+			return nil, err
+		}
+		for _, f := range conflict.TaskInfos {
+			info.Conflicts = append(info.Conflicts, pkg.TaskFile{
+				Id:     f.FileId.String(),
+				Name:   f.FileName,
+				IsDir:  f.IsFolder == 1,
+				Result: pkg.TaskFileConflict,
+			})
+		}
 	}
-	return nil
+	return info, nil
 }
@@ -3,13 +3,13 @@ package web
 import (
 	"encoding/base64"
 	"encoding/hex"
-	"errors"
 	"math/rand"
 	"net/http"
 	"net/url"
 	"strconv"
 	"time"
 
+	"github.com/gowsp/cloud189/pkg"
 	"github.com/gowsp/cloud189/pkg/invoker"
 	"github.com/gowsp/cloud189/pkg/util"
 )
@@ -47,6 +47,26 @@ type uploadResp interface {
 }
 
 func (uploader *api) do(u string, f url.Values, result uploadResp) error {
+	first := true
+	err := uploader.invoker.Request(func() (*http.Request, error) {
+		if !first {
+			// session key may be refreshed
+			uploader.sessionKey = ""
+		}
+		first = false
+		return uploader.uploadRequest(u, f)
+	}, result)
+	if err != nil {
+		return err
+	}
+	if code := result.GetCode(); code != "SUCCESS" {
+		return pkg.NewError(u, code, "")
+	}
+	return nil
+}
+
+// uploadRequest signs request of upload api by session key
+func (uploader *api) uploadRequest(u string, f url.Values) (*http.Request, error) {
 	c := strconv.FormatInt(time.Now().UnixMilli(), 10)
 	r := util.Random("xxxxxxxx-xxxx-4xxx-yxxx-xxxxxxxxxxxx")
 	l := util.Random("xxxxxxxxxxxx4xxxyxxxxxxxxxxxxxxx")
@@ -58,7 +78,7 @@ func (uploader *api) do(u string, f url.Values, result uploadResp) error {
 
 	req, err := http.NewRequest(http.MethodGet, "https://upload.cloud.189.cn"+u+"?params="+h, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	a := make(url.Values)
 	a.Set("SessionKey", uploader.session())
@@ -79,21 +99,5 @@ func (uploader *api) do(u string, f url.Values, result uploadResp) error {
 	req.Header.Set("EncryptionText", base64.StdEncoding.EncodeToString(b))
 	req.Header.Set("PkId", uploader.rsa().PkId)
 
-	if err = uploader.invoker.Do(req, result, 3); err != nil {
-		return err
-	}
-	switch result.GetCode() {
-	case "SUCCESS":
-		return nil
-	case "InvalidSessionKey":
-		uploader.refresh()
-		uploader.sessionKey = ""
-		return uploader.do(u, f, result)
-	case "InvalidSignature":
-		uploader.refresh()
-		uploader.sessionKey = ""
-		return uploader.do(u, f, result)
-	default:
-		return errors.New(result.GetCode())
-	}
+	return req, nil
 }
@@ -0,0 +1,178 @@
+package webdav
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gowsp/cloud189/pkg/auth"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// nonce of digest auth expires after it, clients retry the request with a fresh one
+const nonceTTL = 5 * time.Minute
+
+var errReadOnly = errors.New("webdav: read-only")
+
+// account is a user with the file system under its root
+type account struct {
+	*auth.User
+	fs *CloudFileSystem
+
+	mu sync.Mutex
+	// sum of the password passed bcrypt, basic auth is sent on each request and bcrypt is slow
+	verified [sha256.Size]byte
+}
+
+func (a *account) checkPassword(password string) bool {
+	sum := sha256.Sum256([]byte(password))
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if hmac.Equal(sum[:], a.verified[:]) {
+		return true
+	}
+	if bcrypt.CompareHashAndPassword([]byte(a.Hash), []byte(password)) != nil {
+		return false
+	}
+	a.verified = sum
+	return true
+}
+
+// authenticate returns file system of the user of request, or challenges the client
+func (h *CloudFileSystem) authenticate(w http.ResponseWriter, r *http.Request) (*CloudFileSystem, bool) {
+	var user *account
+	stale := false
+	scheme, params, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	switch strings.ToLower(scheme) {
+	case "basic":
+		name, password, ok := r.BasicAuth()
+		if a := h.users[name]; ok && a != nil && a.checkPassword(password) {
+			user = a
+		}
+	case "digest":
+		user, stale = h.checkDigest(r, parseParams(params))
+	}
+	if user != nil {
+		return user.fs, true
+	}
+	if scheme != "" && !stale {
+		log.Printf("webdav: auth of %s failed from %s", r.URL.Path, r.RemoteAddr)
+	}
+	if h.digest {
+		w.Header().Add("WWW-Authenticate", fmt.Sprintf(`Digest realm="%s", qop="auth", algorithm=MD5, nonce="%s", stale=%t`, auth.Realm, h.nonce(time.Now()), stale))
+	}
+	w.Header().Add("WWW-Authenticate", fmt.Sprintf(`Basic realm="%s", charset="UTF-8"`, auth.Realm))
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	return nil, false
+}
+
+// checkDigest verifies digest auth of RFC 7616, stale reports a valid response with an expired nonce
+func (h *CloudFileSystem) checkDigest(r *http.Request, params map[string]string) (user *account, stale bool) {
+	a := h.users[params["username"]]
+	if a == nil || a.Digest == "" || params["realm"] != auth.Realm || params["uri"] != r.RequestURI {
+		return nil, false
+	}
+	if alg := params["algorithm"]; alg != "" && !strings.EqualFold(alg, "MD5") {
+		return nil, false
+	}
+	valid, expired := h.checkNonce(params["nonce"])
+	if !valid {
+		return nil, false
+	}
+	ha2 := md5Hex(r.Method, params["uri"])
+	var want string
+	switch params["qop"] {
+	case "auth":
+		want = md5Hex(a.Digest, params["nonce"], params["nc"], params["cnonce"], "auth", ha2)
+	case "":
+		want = md5Hex(a.Digest, params["nonce"], ha2)
+	default:
+		return nil, false
+	}
+	if !hmac.Equal([]byte(want), []byte(strings.ToLower(params["response"]))) {
+		return nil, false
+	}
+	if expired {
+		return nil, true
+	}
+	return a, false
+}
+
+// nonce is issue time signed by secret of server, so that no nonce is stored
+func (h *CloudFileSystem) nonce(t time.Time) string {
+	ts := strconv.FormatInt(t.Unix(), 16)
+	return ts + "." + h.sign(ts)
+}
+
+func (h *CloudFileSystem) checkNonce(nonce string) (valid, expired bool) {
+	ts, sig, ok := strings.Cut(nonce, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(h.sign(ts))) {
+		return false, false
+	}
+	issued, err := strconv.ParseInt(ts, 16, 64)
+	if err != nil {
+		return false, false
+	}
+	return true, time.Since(time.Unix(issued, 0)) > nonceTTL
+}
+
+func (h *CloudFileSystem) sign(data string) string {
+	mac := hmac.New(sha256.New, h.secret)
+	mac.Write([]byte(data))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// parseParams parses comma separated key=value or key="value" of auth header
+func parseParams(s string) map[string]string {
+	params := make(map[string]string)
+	for {
+		s = strings.TrimLeft(s, " ,")
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			return params
+		}
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				return params
+			}
+			value, s = rest[1:end+1], rest[end+2:]
+		} else {
+			value, s, _ = strings.Cut(rest, ",")
+		}
+		params[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+	}
+}
+
+func md5Hex(parts ...string) string {
+	sum := md5.Sum([]byte(strings.Join(parts, ":")))
+	return hex.EncodeToString(sum[:])
+}
+
+func newSecret() []byte {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		log.Fatalln(err)
+	}
+	return secret
+}
+
+// readMethod reports whether the method leaves the drive unchanged
+func readMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, "PROPFIND":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,219 @@
+package webdav
+
+import (
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/auth"
+)
+
+// mustFileSystem is NewFileSystem failing the test on error
+func mustFileSystem(t *testing.T, prefix, owner string, d pkg.Drive, opts *Options) *CloudFileSystem {
+	t.Helper()
+	h, err := NewFileSystem(prefix, owner, d, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+// subDrive serves dir of a test drive as root, as sub drive of package drive does
+type subDrive struct {
+	pkg.Drive
+	dir string
+}
+
+func newSubDrive(d pkg.Drive, dir string) (pkg.Drive, error) {
+	info, err := d.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a dir", dir)
+	}
+	return &subDrive{Drive: d, dir: path.Clean("/" + dir)}, nil
+}
+
+func (d *subDrive) Abs(name string) string                     { return path.Join(d.dir, path.Clean("/"+name)) }
+func (d *subDrive) Stat(name string) (fs.FileInfo, error)      { return d.Drive.Stat(d.Abs(name)) }
+func (d *subDrive) Open(name string) (fs.File, error)          { return d.Drive.Open(d.Abs(name)) }
+func (d *subDrive) ReadDir(name string) ([]fs.DirEntry, error) { return d.Drive.ReadDir(d.Abs(name)) }
+func (d *subDrive) Mkdir(name string) error                    { return d.Drive.Mkdir(d.Abs(name)) }
+func (d *subDrive) Rename(oldPath, newName string) error {
+	return d.Drive.Rename(d.Abs(oldPath), newName)
+}
+
+func (d *subDrive) abs(names []string) []string {
+	result := make([]string, len(names))
+	for i, name := range names {
+		result[i] = d.Abs(name)
+	}
+	return result
+}
+
+func (d *subDrive) Delete(cfg pkg.TaskConfig, name ...string) error {
+	return d.Drive.Delete(cfg, d.abs(name)...)
+}
+
+func (d *subDrive) Copy(cfg pkg.TaskConfig, target string, source ...string) error {
+	return d.Drive.Copy(cfg, d.Abs(target), d.abs(source)...)
+}
+
+func (d *subDrive) Move(cfg pkg.TaskConfig, target string, source ...string) error {
+	return d.Drive.Move(cfg, d.Abs(target), d.abs(source)...)
+}
+
+func (d *propDrive) Sub(dir string) (pkg.Drive, error)   { return newSubDrive(d, dir) }
+func (d *uploadDrive) Sub(dir string) (pkg.Drive, error) { return newSubDrive(d, dir) }
+
+func newTestUser(t *testing.T, name, password, root string, readOnly bool) *auth.User {
+	u, err := auth.NewUser(name, password, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.Root, u.ReadOnly = root, readOnly
+	return u
+}
+
+func TestBasicAuth(t *testing.T) {
+	d := &propDrive{memDrive: newMemDrive("/a.txt", "/dir/")}
+	h := mustFileSystem(t, "", "", d, &Options{Users: []*auth.User{newTestUser(t, "alice", "secret", "", false)}})
+
+	w := serve(h, "PROPFIND", "/", map[string]string{"Depth": "0"}, "")
+	// digest is not offered without a user enabling it
+	if w.Code != http.StatusUnauthorized || len(w.Header().Values("WWW-Authenticate")) != 1 {
+		t.Errorf("anonymous: status %d, challenge %v", w.Code, w.Header().Values("WWW-Authenticate"))
+	}
+	mkcol := func(password string) int {
+		req := httptest.NewRequest("MKCOL", "/new", nil)
+		req.SetBasicAuth("alice", password)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := mkcol("wrong"); code != http.StatusUnauthorized || d.paths["/new/"] {
+		t.Errorf("wrong password: status %d", code)
+	}
+	if code := mkcol("secret"); code != http.StatusCreated || !d.paths["/new/"] {
+		t.Errorf("mkcol: status %d", code)
+	}
+	req := httptest.NewRequest("DELETE", "/a.txt", nil)
+	req.SetBasicAuth("bob", "secret")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized || !d.paths["/a.txt"] {
+		t.Errorf("unknown user: status %d", w.Code)
+	}
+}
+
+func TestDigestAuth(t *testing.T) {
+	alice, err := auth.NewUser("alice", "secret", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := []*auth.User{alice, newTestUser(t, "bob", "secret", "", false)}
+	h := mustFileSystem(t, "", "", &propDrive{memDrive: newMemDrive("/a.txt")}, &Options{Users: users})
+
+	w := serve(h, "PROPFIND", "/a.txt", nil, "")
+	var challenge map[string]string
+	for _, v := range w.Header().Values("WWW-Authenticate") {
+		if scheme, params, _ := strings.Cut(v, " "); scheme == "Digest" {
+			challenge = parseParams(params)
+		}
+	}
+	if challenge == nil || challenge["realm"] != auth.Realm || challenge["nonce"] == "" {
+		t.Fatalf("digest challenge %v", w.Header().Values("WWW-Authenticate"))
+	}
+	authorize := func(name, password, nonce string) int {
+		ha1 := md5Hex(name, auth.Realm, password)
+		response := md5Hex(ha1, nonce, "00000001", "abc", "auth", md5Hex("PROPFIND", "/a.txt"))
+		header := `Digest username="` + name + `", realm="` + auth.Realm + `", nonce="` + nonce +
+			`", uri="/a.txt", qop=auth, nc=00000001, cnonce="abc", response="` + response + `"`
+		return serve(h, "PROPFIND", "/a.txt", map[string]string{"Authorization": header, "Depth": "0"}, "").Code
+	}
+	if code := authorize("alice", "secret", challenge["nonce"]); code != http.StatusMultiStatus {
+		t.Errorf("digest auth: status %d", code)
+	}
+	if code := authorize("alice", "wrong", challenge["nonce"]); code != http.StatusUnauthorized {
+		t.Errorf("wrong password: status %d", code)
+	}
+	if code := authorize("alice", "secret", "0."+challenge["nonce"]); code != http.StatusUnauthorized {
+		t.Errorf("forged nonce: status %d", code)
+	}
+	// digest auth is opt-in, a user without digest hash passes basic auth only
+	if code := authorize("bob", "secret", challenge["nonce"]); code != http.StatusUnauthorized {
+		t.Errorf("digest of user without it: status %d", code)
+	}
+	stale := h.nonce(time.Now().Add(-2 * nonceTTL))
+	w = serve(h, "PROPFIND", "/a.txt", map[string]string{"Authorization": `Digest username="alice", realm="` + auth.Realm +
+		`", nonce="` + stale + `", uri="/a.txt", response="` + md5Hex(md5Hex("alice", auth.Realm, "secret"), stale, md5Hex("PROPFIND", "/a.txt")) + `"`}, "")
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Header().Get("WWW-Authenticate"), "stale=true") {
+		t.Errorf("expired nonce: status %d, challenge %s", w.Code, w.Header().Get("WWW-Authenticate"))
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	d := &propDrive{memDrive: newMemDrive("/a.txt", "/dir/")}
+	h := mustFileSystem(t, "", "", d, &Options{ReadOnly: true})
+	before := d.list()
+	for _, method := range []string{"PUT", "DELETE", "MKCOL", "COPY", "MOVE", "PROPPATCH", "LOCK"} {
+		w := serve(h, method, "/a.txt", map[string]string{"Destination": "/b.txt"}, "data")
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s: status %d", method, w.Code)
+		}
+	}
+	if d.list() != before {
+		t.Errorf("read-only drive changed: %s", d.list())
+	}
+	if w := serve(h, "PROPFIND", "/", map[string]string{"Depth": "1"}, ""); w.Code != http.StatusMultiStatus {
+		t.Errorf("propfind: status %d", w.Code)
+	}
+}
+
+func TestUserRoot(t *testing.T) {
+	d := &propDrive{memDrive: newMemDrive("/secret.txt", "/home/", "/home/alice/", "/home/alice/a.txt")}
+	users := []*auth.User{
+		newTestUser(t, "alice", "secret", "/home/alice", false),
+		newTestUser(t, "guest", "guest", "/home/alice", true),
+	}
+	h := mustFileSystem(t, "", "", d, &Options{Users: users})
+	do := func(user, password, method, target string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.SetBasicAuth(user, password)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("alice", "secret", "PROPFIND", "/", map[string]string{"Depth": "1"})
+	if w.Code != http.StatusMultiStatus || !strings.Contains(w.Body.String(), "<D:href>/a.txt</D:href>") ||
+		strings.Contains(w.Body.String(), "secret.txt") {
+		t.Errorf("propfind of root: status %d, %s", w.Code, w.Body)
+	}
+	if w = do("alice", "secret", "PROPFIND", "/../secret.txt", map[string]string{"Depth": "0"}); w.Code == http.StatusMultiStatus {
+		t.Errorf("escape of root: %s", w.Body)
+	}
+	if w = do("alice", "secret", "COPY", "/a.txt", map[string]string{"Destination": "/b.txt"}); w.Code != http.StatusCreated || !d.paths["/home/alice/b.txt"] {
+		t.Errorf("copy in root: status %d, %s", w.Code, d.list())
+	}
+	if w = do("guest", "guest", "DELETE", "/a.txt", nil); w.Code != http.StatusForbidden || !d.paths["/home/alice/a.txt"] {
+		t.Errorf("delete of read-only user: status %d", w.Code)
+	}
+	if w = do("guest", "guest", "PROPFIND", "/a.txt", map[string]string{"Depth": "0"}); w.Code != http.StatusMultiStatus {
+		t.Errorf("propfind of read-only user: status %d", w.Code)
+	}
+	missing := &Options{Users: []*auth.User{newTestUser(t, "bob", "secret", "/home/bob", false)}}
+	if _, err := NewFileSystem("", "", d, missing); err == nil {
+		t.Error("root of user does not exist")
+	}
+}
@@ -2,14 +2,22 @@ package webdav
 
 import (
 	"errors"
+	"fmt"
+	"io/fs"
 	"net/http"
 	"net/url"
+	"path"
 	"strings"
+
+	"github.com/gowsp/cloud189/pkg"
 )
 
 var errPrefixMismatch = errors.New("webdav: prefix mismatch")
 var errDestinationEqualsSource = errors.New("webdav: destination equals source")
 var errInvalidDestination = errors.New("webdav: invalid destination")
+var errDestinationInSource = errors.New("webdav: destination is inside source")
+var errInvalidDepth = errors.New("webdav: invalid depth")
+var errMissingParent = errors.New("webdav: parent of destination does not exist")
 
 func (h *CloudFileSystem) stripPrefix(p string) (string, int, error) {
 	if h.Prefix == "" {
@@ -47,12 +55,103 @@ func (h *CloudFileSystem) handleCopyMove(w http.ResponseWriter, r *http.Request)
 	if dst == "" {
 		return http.StatusBadGateway, errInvalidDestination
 	}
+	src, dst = path.Clean("/"+src), path.Clean("/"+dst)
 	if dst == src {
 		return http.StatusForbidden, errDestinationEqualsSource
 	}
-	err = h.app.Copy(dst, src)
+	if src == "/" || strings.HasPrefix(dst, src+"/") {
+		return http.StatusForbidden, errDestinationInSource
+	}
+	// COPY leaves source unchanged, only its destination is confirmed
+	locked := ""
+	if r.Method == "MOVE" {
+		locked = src
+	}
+	release, status, err := h.confirmLocks(r, locked, dst)
+	if err != nil {
+		return status, err
+	}
+	defer release()
+
+	// Section 9.8.3 and 9.9.2, COPY takes Depth 0 or infinity, MOVE takes infinity only
+	depth := r.Header.Get("Depth")
+	switch {
+	case depth == "" || strings.EqualFold(depth, "infinity"):
+		depth = "infinity"
+	case depth != "0" || r.Method == "MOVE":
+		return http.StatusBadRequest, errInvalidDepth
+	}
+
+	source, err := h.app.Stat(src)
 	if err != nil {
-		return http.StatusForbidden, err
+		return pkg.StatusCode(err, http.StatusInternalServerError), err
+	}
+	// Section 9.8.5, the parent of destination must exist
+	dir, name := path.Split(dst)
+	parent, err := h.app.Stat(dir)
+	if err != nil || !parent.IsDir() {
+		return http.StatusConflict, errMissingParent
+	}
+	// Section 10.6, Overwrite defaults to T, F fails with 412 when destination exists
+	_, err = h.app.Stat(dst)
+	exists := err == nil
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return pkg.StatusCode(err, http.StatusInternalServerError), err
+	}
+	if exists {
+		if r.Header.Get("Overwrite") == "F" {
+			return http.StatusPreconditionFailed, fs.ErrExist
+		}
+		if err = h.app.Delete(pkg.TaskConfig{}, dst); err != nil {
+			return pkg.StatusCode(err, http.StatusInternalServerError), err
+		}
+	}
+
+	switch {
+	case r.Method == "MOVE":
+		// renamed in place or relocated, renamed again when the name changes
+		err = h.app.Move(pkg.TaskConfig{}, dst, src)
+	case depth == "0" && source.IsDir():
+		err = h.app.Mkdir(dst)
+	default:
+		err = h.copyAs(dir, name, src)
+	}
+	if err != nil {
+		return pkg.StatusCode(err, http.StatusInternalServerError), err
+	}
+	if exists {
+		return http.StatusNoContent, nil
 	}
 	return http.StatusCreated, nil
 }
+
+// copyAs copies src into dir and names the copy, which is renamed by server on conflict
+func (h *CloudFileSystem) copyAs(dir, name, src string) error {
+	before, err := h.app.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	names := make(map[string]bool, len(before))
+	for _, entry := range before {
+		names[entry.Name()] = true
+	}
+	if err = h.app.Copy(pkg.TaskConfig{Conflict: pkg.ConflictRename}, dir, src); err != nil {
+		return err
+	}
+	if path.Base(src) == name && !names[name] {
+		return nil
+	}
+	after, err := h.app.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range after {
+		if !names[entry.Name()] {
+			if entry.Name() == name {
+				return nil
+			}
+			return h.app.Rename(path.Join(dir, entry.Name()), name)
+		}
+	}
+	return fmt.Errorf("copy of %s is not found in %s", src, dir)
+}
@@ -0,0 +1,228 @@
+package webdav
+
+import (
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gowsp/cloud189/pkg"
+)
+
+// memDrive is a drive of paths in memory, a dir has trailing slash in paths
+type memDrive struct {
+	pkg.Drive
+	paths map[string]bool
+}
+
+func newMemDrive(paths ...string) *memDrive {
+	d := &memDrive{paths: map[string]bool{"/": true}}
+	for _, p := range paths {
+		d.paths[p] = true
+	}
+	return d
+}
+
+type memFile struct {
+	name string
+	dir  bool
+}
+
+func (f *memFile) Id() string         { return f.name }
+func (f *memFile) PId() string        { return "" }
+func (f *memFile) Name() string       { return path.Base(f.name) }
+func (f *memFile) Size() int64        { return 0 }
+func (f *memFile) Mode() fs.FileMode  { return fs.ModePerm }
+func (f *memFile) ModTime() time.Time { return time.Time{} }
+func (f *memFile) IsDir() bool        { return f.dir }
+func (f *memFile) Sys() any           { return nil }
+
+func (d *memDrive) lookup(name string) (*memFile, bool) {
+	name = path.Clean(name)
+	if name == "/" {
+		return &memFile{name: "/", dir: true}, true
+	}
+	if d.paths[name+"/"] {
+		return &memFile{name: name, dir: true}, true
+	}
+	return &memFile{name: name}, d.paths[name]
+}
+
+func (d *memDrive) Stat(name string) (fs.FileInfo, error) {
+	f, ok := d.lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, fs.ErrNotExist)
+	}
+	return f, nil
+}
+
+func (d *memDrive) ReadDir(name string) (entries []fs.DirEntry, err error) {
+	dir := strings.TrimSuffix(path.Clean(name), "/") + "/"
+	for p := range d.paths {
+		rest := strings.TrimPrefix(p, dir)
+		if rest == p || rest == "" || strings.Contains(strings.TrimSuffix(rest, "/"), "/") {
+			continue
+		}
+		f, _ := d.lookup(strings.TrimSuffix(p, "/"))
+		entries = append(entries, fs.FileInfoToDirEntry(f))
+	}
+	return entries, nil
+}
+
+func (d *memDrive) Mkdir(name string) error {
+	if _, ok := d.lookup(name); ok {
+		return fs.ErrExist
+	}
+	d.paths[path.Clean(name)+"/"] = true
+	return nil
+}
+
+func (d *memDrive) Delete(cfg pkg.TaskConfig, name ...string) error {
+	for _, n := range name {
+		n = path.Clean(n)
+		for p := range d.paths {
+			if p == n || strings.HasPrefix(p, n+"/") {
+				delete(d.paths, p)
+			}
+		}
+	}
+	return nil
+}
+
+// relocate copies or moves tree of src to dst
+func (d *memDrive) relocate(src, dst string, keep bool) {
+	for p := range d.paths {
+		if p == src || strings.HasPrefix(p, src+"/") {
+			d.paths[dst+strings.TrimPrefix(p, src)] = true
+			if !keep {
+				delete(d.paths, p)
+			}
+		}
+	}
+}
+
+func (d *memDrive) Copy(cfg pkg.TaskConfig, target string, source ...string) error {
+	for _, src := range source {
+		name := path.Base(src)
+		if _, ok := d.lookup(path.Join(target, name)); ok {
+			if cfg.Policy() != pkg.ConflictRename {
+				return fs.ErrExist
+			}
+			// server names the copy like "name(1)"
+			name += "(1)"
+		}
+		d.relocate(path.Clean(src), path.Join(target, name), true)
+	}
+	return nil
+}
+
+func (d *memDrive) Move(cfg pkg.TaskConfig, target string, source ...string) error {
+	src := path.Clean(source[0])
+	if f, ok := d.lookup(target); ok && f.dir {
+		target = path.Join(target, path.Base(src))
+	}
+	if _, ok := d.lookup(target); ok {
+		return fs.ErrExist
+	}
+	d.relocate(src, path.Clean(target), false)
+	return nil
+}
+
+func (d *memDrive) Rename(oldPath, newName string) error {
+	return d.Move(pkg.TaskConfig{}, path.Join(path.Dir(oldPath), newName), oldPath)
+}
+
+func (d *memDrive) list() string {
+	var paths []string
+	for p := range d.paths {
+		if p != "/" {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	return strings.Join(paths, " ")
+}
+
+func TestCopyMove(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		src, dst  string
+		header    map[string]string
+		status    int
+		remaining string
+	}{
+		{"copy file", "COPY", "/a.txt", "/b.txt", nil, 201,
+			"/a.txt /b.txt /c.txt /dir/ /dir/x.txt /other/ /other/a.txt"},
+		{"copy into dir", "COPY", "/c.txt", "/dir/c.txt", nil, 201,
+			"/a.txt /c.txt /dir/ /dir/c.txt /dir/x.txt /other/ /other/a.txt"},
+		{"copy overwrite", "COPY", "/a.txt", "/c.txt", nil, 204,
+			"/a.txt /c.txt /dir/ /dir/x.txt /other/ /other/a.txt"},
+		{"copy no overwrite", "COPY", "/a.txt", "/c.txt", map[string]string{"Overwrite": "F"}, 412,
+			"/a.txt /c.txt /dir/ /dir/x.txt /other/ /other/a.txt"},
+		{"copy renamed into dir with same name", "COPY", "/a.txt", "/other/z.txt", nil, 201,
+			"/a.txt /c.txt /dir/ /dir/x.txt /other/ /other/a.txt /other/z.txt"},
+		{"copy dir", "COPY", "/dir", "/dir2", nil, 201,
+			"/a.txt /c.txt /dir/ /dir/x.txt /dir2/ /dir2/x.txt /other/ /other/a.txt"},
+		{"copy dir depth 0", "COPY", "/dir", "/dir2", map[string]string{"Depth": "0"}, 201,
+			"/a.txt /c.txt /dir/ /dir/x.txt /dir2/ /other/ /other/a.txt"},
+		{"copy depth 1", "COPY", "/dir", "/dir2", map[string]string{"Depth": "1"}, 400, ""},
+		{"copy missing parent", "COPY", "/a.txt", "/none/a.txt", nil, 409, ""},
+		{"copy missing source", "COPY", "/none.txt", "/b.txt", nil, 404, ""},
+		{"copy to self", "COPY", "/a.txt", "/a.txt", nil, 403, ""},
+		{"copy into itself", "COPY", "/dir", "/dir/sub", nil, 403, ""},
+		{"rename", "MOVE", "/a.txt", "/b.txt", nil, 201,
+			"/b.txt /c.txt /dir/ /dir/x.txt /other/ /other/a.txt"},
+		{"relocate", "MOVE", "/c.txt", "/dir/c.txt", nil, 201,
+			"/a.txt /dir/ /dir/c.txt /dir/x.txt /other/ /other/a.txt"},
+		{"relocate and rename", "MOVE", "/c.txt", "/dir/d.txt", nil, 201,
+			"/a.txt /dir/ /dir/d.txt /dir/x.txt /other/ /other/a.txt"},
+		{"move overwrite", "MOVE", "/a.txt", "/other/a.txt", nil, 204,
+			"/c.txt /dir/ /dir/x.txt /other/ /other/a.txt"},
+		{"move no overwrite", "MOVE", "/a.txt", "/c.txt", map[string]string{"Overwrite": "F"}, 412, ""},
+		{"move dir", "MOVE", "/dir", "/other/dir", nil, 201,
+			"/a.txt /c.txt /other/ /other/a.txt /other/dir/ /other/dir/x.txt"},
+		{"move depth 0", "MOVE", "/dir", "/dir2", map[string]string{"Depth": "0"}, 400, ""},
+		{"move missing parent", "MOVE", "/a.txt", "/none/a.txt", nil, 409, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := newMemDrive("/a.txt", "/c.txt", "/dir/", "/dir/x.txt", "/other/", "/other/a.txt")
+			before := d.list()
+			h := mustFileSystem(t, "/dav", "", d, &Options{})
+			req := httptest.NewRequest(test.method, "/dav"+test.src, nil)
+			req.Header.Set("Destination", "http://"+req.Host+"/dav"+test.dst)
+			for k, v := range test.header {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != test.status {
+				t.Errorf("status %d, want %d", w.Code, test.status)
+			}
+			want := test.remaining
+			if want == "" {
+				want = before
+			}
+			if got := d.list(); got != want {
+				t.Errorf("paths %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestCopyOtherHost(t *testing.T) {
+	h := mustFileSystem(t, "", "", newMemDrive("/a.txt"), &Options{})
+	req := httptest.NewRequest("COPY", "/a.txt", nil)
+	req.Header.Set("Destination", "http://other.host/b.txt")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("status %d", w.Code)
+	}
+}
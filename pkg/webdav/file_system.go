@@ -6,28 +6,59 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"sync"
+	"time"
 
 	"golang.org/x/net/webdav"
 
 	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/file"
 )
 
 var errUnsupportedMethod = errors.New("webdav: unsupported method")
 
 type CloudFileSystem struct {
-	app     pkg.Drive
-	Prefix  string
-	handler *webdav.Handler
+	app      pkg.Drive
+	Prefix   string
+	handler  *webdav.Handler
+	conflict pkg.ConflictPolicy
+	spool    *file.Spool
+	// account owning the drive, locks are named under it so that drives of different accounts keep apart
+	owner    string
+	readOnly bool
+	// file systems of users by name, nil serves without auth
+	users  map[string]*account
+	secret []byte
+	// some user has digest hash, digest auth is offered to clients
+	digest bool
+
+	mu      sync.Mutex
+	space   pkg.Space
+	spaceAt time.Time
+	// space has been got once, the last one is served when it fails
+	spaceOk bool
 }
 
 func (h *CloudFileSystem) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.users != nil {
+		fs, ok := h.authenticate(w, r)
+		if !ok {
+			return
+		}
+		h = fs
+	}
+	if h.readOnly && !readMethod(r.Method) {
+		log.Println(r.Method, r.URL.Path, errReadOnly)
+		http.Error(w, webdav.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
 	useNative := true
 	status, err := http.StatusBadRequest, errUnsupportedMethod
 	switch r.Method {
 	case "PUT":
 		useNative = false
 		status, err = h.handlePut(w, r)
-	case "COPY":
+	case "COPY", "MOVE":
 		useNative = false
 		status, err = h.handleCopyMove(w, r)
 	}
@@ -54,14 +85,18 @@ func (f *CloudFileSystem) OpenFile(ctx context.Context, name string, flag int, p
 	if flag&os.O_CREATE != 0 {
 		return empty, nil
 	}
-	return newRead(f.app, name)
+	return newRead(f, name)
 }
 func (f *CloudFileSystem) RemoveAll(ctx context.Context, name string) error {
-	return f.app.Delete(name)
+	return f.app.Delete(pkg.TaskConfig{}, name)
 }
 func (f *CloudFileSystem) Rename(ctx context.Context, oldName, newName string) error {
-	return f.app.Move(newName, oldName)
+	return f.app.Move(pkg.TaskConfig{}, newName, oldName)
 }
 func (f *CloudFileSystem) Stat(ctx context.Context, name string) (os.FileInfo, error) {
-	return f.app.Stat(name)
+	info, err := f.app.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	return wrapInfo(info), nil
 }
@@ -0,0 +1,444 @@
+package webdav
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gowsp/cloud189/pkg/drive"
+	"golang.org/x/net/webdav"
+)
+
+// fileLock is a lock saved in file
+type fileLock struct {
+	Token     string `json:"token"`
+	Root      string `json:"root"`
+	Owner     string `json:"owner,omitempty"`
+	ZeroDepth bool   `json:"zeroDepth,omitempty"`
+	// zero for infinite timeout
+	Expiry time.Time `json:"expiry,omitempty"`
+	// taken by webdav handler for a request without lock, kept in memory only
+	transient bool
+	// claimed by a request in progress
+	held bool
+}
+
+func (l *fileLock) details(now time.Time) webdav.LockDetails {
+	d := webdav.LockDetails{Root: l.Root, OwnerXML: l.Owner, ZeroDepth: l.ZeroDepth, Duration: -1}
+	if !l.Expiry.IsZero() {
+		d.Duration = l.Expiry.Sub(now).Round(time.Second)
+	}
+	return d
+}
+
+// covers reports whether the lock protects name
+func (l *fileLock) covers(name string) bool {
+	return name == l.Root || !l.ZeroDepth && under(name, l.Root)
+}
+
+func under(name, dir string) bool {
+	return name != dir && (dir == "/" || strings.HasPrefix(name, dir+"/"))
+}
+
+// FileLS is a webdav.LockSystem saving locks in a file, which is reloaded once changed by
+// other processes, so that locks survive restarts and are seen by other servers
+type FileLS struct {
+	path  string
+	mu    sync.Mutex
+	locks map[string]*fileLock
+	// of the file last read or written, a change means other process saved it
+	modTime time.Time
+	size    int64
+}
+
+// OpenFileLS opens locks saved in path
+func OpenFileLS(path string) (*FileLS, error) {
+	l := &FileLS{path: path, locks: make(map[string]*fileLock)}
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// load reads locks when the file is changed, locks held or taken by this process are kept
+func (l *FileLS) load() error {
+	stat, err := os.Stat(l.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil || stat.ModTime().Equal(l.modTime) && stat.Size() == l.size {
+		return err
+	}
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		return err
+	}
+	var saved []*fileLock
+	if len(data) > 0 {
+		if err = json.Unmarshal(data, &saved); err != nil {
+			return err
+		}
+	}
+	locks := make(map[string]*fileLock, len(saved))
+	for _, v := range saved {
+		if old := l.locks[v.Token]; old != nil {
+			v.held = old.held
+		}
+		locks[v.Token] = v
+	}
+	for token, v := range l.locks {
+		if v.transient {
+			locks[token] = v
+		}
+	}
+	l.locks, l.modTime, l.size = locks, stat.ModTime(), stat.Size()
+	return nil
+}
+
+// save writes locks by renaming a temp file, so that readers never see a partial file
+func (l *FileLS) save() error {
+	saved := make([]*fileLock, 0, len(l.locks))
+	for _, v := range l.locks {
+		if !v.transient {
+			saved = append(saved, v)
+		}
+	}
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(l.path), filepath.Base(l.path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), l.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if stat, err := os.Stat(l.path); err == nil {
+		l.modTime, l.size = stat.ModTime(), stat.Size()
+	}
+	return nil
+}
+
+// refresh reloads locks and drops expired ones, changed reports whether the file needs saving
+func (l *FileLS) refresh(now time.Time) (changed bool, err error) {
+	if err = l.load(); err != nil {
+		return false, err
+	}
+	for token, v := range l.locks {
+		if !v.held && !v.Expiry.IsZero() && !now.Before(v.Expiry) {
+			delete(l.locks, token)
+			changed = changed || !v.transient
+		}
+	}
+	return changed, nil
+}
+
+func (l *FileLS) Confirm(now time.Time, name0, name1 string, conditions ...webdav.Condition) (func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	changed, err := l.refresh(now)
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		if err = l.save(); err != nil {
+			return nil, err
+		}
+	}
+	var n0, n1 *fileLock
+	if name0 != "" {
+		if n0 = l.lookup(path.Clean("/"+name0), conditions...); n0 == nil {
+			return nil, webdav.ErrConfirmationFailed
+		}
+	}
+	if name1 != "" {
+		if n1 = l.lookup(path.Clean("/"+name1), conditions...); n1 == nil {
+			return nil, webdav.ErrConfirmationFailed
+		}
+	}
+	if n1 == n0 {
+		n1 = nil
+	}
+	for _, n := range []*fileLock{n0, n1} {
+		if n != nil {
+			n.held = true
+		}
+	}
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		for _, n := range []*fileLock{n0, n1} {
+			if n != nil {
+				n.held = false
+			}
+		}
+	}, nil
+}
+
+// lookup returns the lock of conditions covering name, which is not held by another request
+func (l *FileLS) lookup(name string, conditions ...webdav.Condition) *fileLock {
+	for _, c := range conditions {
+		if n := l.locks[c.Token]; n != nil && !n.held && n.covers(name) {
+			return n
+		}
+	}
+	return nil
+}
+
+func (l *FileLS) Create(now time.Time, details webdav.LockDetails) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.refresh(now); err != nil {
+		return "", err
+	}
+	root := path.Clean("/" + details.Root)
+	for _, v := range l.locks {
+		if v.covers(root) || !details.ZeroDepth && under(v.Root, root) {
+			return "", webdav.ErrLocked
+		}
+	}
+	token, err := newLockToken()
+	if err != nil {
+		return "", err
+	}
+	n := &fileLock{Token: token, Root: root, Owner: details.OwnerXML, ZeroDepth: details.ZeroDepth}
+	if details.Duration >= 0 {
+		n.Expiry = now.Add(details.Duration)
+	}
+	// handler locks the resource of a write request without lock by an infinite zero-depth lock,
+	// it is released at the end of request and would be left forever if saved
+	n.transient = details.Duration < 0 && details.ZeroDepth && details.OwnerXML == ""
+	l.locks[token] = n
+	if n.transient {
+		return token, nil
+	}
+	if err = l.save(); err != nil {
+		delete(l.locks, token)
+		return "", err
+	}
+	return token, nil
+}
+
+func (l *FileLS) Refresh(now time.Time, token string, duration time.Duration) (webdav.LockDetails, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.refresh(now); err != nil {
+		return webdav.LockDetails{}, err
+	}
+	n := l.locks[token]
+	if n == nil {
+		return webdav.LockDetails{}, webdav.ErrNoSuchLock
+	}
+	if n.held {
+		return webdav.LockDetails{}, webdav.ErrLocked
+	}
+	n.Expiry = time.Time{}
+	if duration >= 0 {
+		n.Expiry = now.Add(duration)
+	}
+	if !n.transient {
+		if err := l.save(); err != nil {
+			return webdav.LockDetails{}, err
+		}
+	}
+	return n.details(now), nil
+}
+
+func (l *FileLS) Unlock(now time.Time, token string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	changed, err := l.refresh(now)
+	if err != nil {
+		return err
+	}
+	n := l.locks[token]
+	if n == nil {
+		if changed {
+			if err = l.save(); err != nil {
+				return err
+			}
+		}
+		return webdav.ErrNoSuchLock
+	}
+	if n.held {
+		return webdav.ErrLocked
+	}
+	delete(l.locks, token)
+	if n.transient && !changed {
+		return nil
+	}
+	return l.save()
+}
+
+// Locked returns webdav.ErrLocked when a lock covers any of names or their descendants,
+// servers changing files without lock tokens check it first
+func (l *FileLS) Locked(names ...string) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.refresh(time.Now()); err != nil {
+		return err
+	}
+	for _, name := range names {
+		name = path.Clean("/" + name)
+		for _, v := range l.locks {
+			if v.covers(name) || under(v.Root, name) {
+				return &fs.PathError{Op: "lock", Path: name, Err: webdav.ErrLocked}
+			}
+		}
+	}
+	return nil
+}
+
+func newLockToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "opaquelocktoken:" + hex.EncodeToString(b), nil
+}
+
+// rootLocks names locks by account and cloud path from root of the whole drive,
+// so that users of different roots and other servers of the account agree on them
+type rootLocks struct {
+	webdav.LockSystem
+	fs *CloudFileSystem
+}
+
+// LockName returns name of lock on cloud path of account, name is the path from root of the whole drive
+func LockName(account, name string) string {
+	return path.Join("/", account, path.Clean("/"+name))
+}
+
+func (r *rootLocks) name(name string) string {
+	return LockName(r.fs.owner, drive.Abs(r.fs.app, name))
+}
+
+func (r *rootLocks) Confirm(now time.Time, name0, name1 string, conditions ...webdav.Condition) (func(), error) {
+	if name0 != "" {
+		name0 = r.name(name0)
+	}
+	if name1 != "" {
+		name1 = r.name(name1)
+	}
+	return r.LockSystem.Confirm(now, name0, name1, conditions...)
+}
+
+func (r *rootLocks) Create(now time.Time, details webdav.LockDetails) (string, error) {
+	details.Root = r.name(details.Root)
+	return r.LockSystem.Create(now, details)
+}
+
+func (r *rootLocks) Refresh(now time.Time, token string, duration time.Duration) (webdav.LockDetails, error) {
+	details, err := r.LockSystem.Refresh(now, token, duration)
+	if base := r.name("/"); err == nil && base != "/" {
+		details.Root = path.Clean("/" + strings.TrimPrefix(details.Root, base))
+	}
+	return details, err
+}
+
+// confirmLocks makes sure src and dst are not locked by others before custom handlers change them,
+// as webdav.Handler does for its methods. Resource tags of If header are not distinguished.
+func (h *CloudFileSystem) confirmLocks(r *http.Request, src, dst string) (release func(), status int, err error) {
+	ls := h.handler.LockSystem
+	now := time.Now()
+	hdr := r.Header.Get("If")
+	if hdr == "" {
+		var tokens []string
+		release = func() {
+			for _, token := range tokens {
+				ls.Unlock(now, token)
+			}
+		}
+		for _, name := range []string{src, dst} {
+			if name == "" {
+				continue
+			}
+			token, err := ls.Create(now, webdav.LockDetails{Root: name, Duration: -1, ZeroDepth: true})
+			if err != nil {
+				release()
+				if err == webdav.ErrLocked {
+					return nil, webdav.StatusLocked, err
+				}
+				return nil, http.StatusInternalServerError, err
+			}
+			tokens = append(tokens, token)
+		}
+		return release, 0, nil
+	}
+	lists, ok := parseIfLists(hdr)
+	if !ok {
+		return nil, http.StatusBadRequest, errInvalidIfHeader
+	}
+	for _, conditions := range lists {
+		release, err = ls.Confirm(now, src, dst, conditions...)
+		if err == webdav.ErrConfirmationFailed {
+			continue
+		}
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		return release, 0, nil
+	}
+	return nil, http.StatusPreconditionFailed, webdav.ErrLocked
+}
+
+// parseIfLists parses condition lists in parentheses of If header, such as
+// <http://host/a> (<token1>) (Not <token2> ["etag"])
+func parseIfLists(hdr string) (lists [][]webdav.Condition, ok bool) {
+	for {
+		start := strings.IndexByte(hdr, '(')
+		if start < 0 {
+			return lists, len(lists) > 0
+		}
+		end := strings.IndexByte(hdr[start:], ')')
+		if end < 0 {
+			return nil, false
+		}
+		var conditions []webdav.Condition
+		s := strings.TrimSpace(hdr[start+1 : start+end])
+		for s != "" {
+			var c webdav.Condition
+			if rest, not := strings.CutPrefix(s, "Not"); not {
+				c.Not, s = true, strings.TrimSpace(rest)
+			}
+			if s == "" {
+				return nil, false
+			}
+			closing := map[byte]byte{'<': '>', '[': ']'}[s[0]]
+			i := strings.IndexByte(s, closing)
+			if closing == 0 || i < 0 {
+				return nil, false
+			}
+			if s[0] == '<' {
+				c.Token = s[1:i]
+			} else {
+				c.ETag = s[1:i]
+			}
+			conditions = append(conditions, c)
+			s = strings.TrimSpace(s[i+1:])
+		}
+		lists = append(lists, conditions)
+		hdr = hdr[start+end+1:]
+	}
+}
@@ -0,0 +1,116 @@
+package webdav
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gowsp/cloud189/pkg/auth"
+	"golang.org/x/net/webdav"
+)
+
+func TestFileLS(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "locks.json")
+	ls, err := OpenFileLS(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	token, err := ls.Create(now, webdav.LockDetails{Root: "/dir", Duration: time.Hour, OwnerXML: "<D:href>me</D:href>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ls.Create(now, webdav.LockDetails{Root: "/dir/a.txt", Duration: time.Hour, ZeroDepth: true}); err != webdav.ErrLocked {
+		t.Errorf("lock inside infinite lock: %v", err)
+	}
+	if _, err = ls.Create(now, webdav.LockDetails{Root: "/", Duration: time.Hour}); err != webdav.ErrLocked {
+		t.Errorf("infinite lock of parent: %v", err)
+	}
+	expired, err := ls.Create(now.Add(-2*time.Hour), webdav.LockDetails{Root: "/old", Duration: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ls.Create(now, webdav.LockDetails{Root: "/tmp", Duration: -1, ZeroDepth: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	// another process sees saved locks, but not request locks or expired ones
+	other, err := OpenFileLS(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, locked := range map[string]bool{"/dir": true, "/dir/a.txt": true, "/": true, "/other": false, "/old": false, "/tmp": false} {
+		if err := other.Locked(name); (err != nil) != locked || err != nil && !errors.Is(err, webdav.ErrLocked) {
+			t.Errorf("%s locked: %v", name, err)
+		}
+	}
+	if err = other.Unlock(now, expired); err != webdav.ErrNoSuchLock {
+		t.Errorf("unlock expired: %v", err)
+	}
+	details, err := other.Refresh(now, token, time.Minute)
+	if err != nil || details.Root != "/dir" || details.Duration != time.Minute {
+		t.Errorf("refresh %+v, %v", details, err)
+	}
+	release, err := ls.Confirm(now, "/dir/a.txt", "", webdav.Condition{Token: token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ls.Confirm(now, "/dir/b.txt", "", webdav.Condition{Token: token}); err != webdav.ErrConfirmationFailed {
+		t.Errorf("confirm held lock: %v", err)
+	}
+	release()
+	if err = other.Unlock(now, token); err != nil {
+		t.Fatal(err)
+	}
+	if err = ls.Locked("/dir"); err != nil {
+		t.Errorf("unlocked by other process: %v", err)
+	}
+}
+
+func TestLockedWrite(t *testing.T) {
+	ls, err := OpenFileLS(filepath.Join(t.TempDir(), "locks.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &uploadDrive{propDrive: &propDrive{memDrive: newMemDrive("/home/", "/home/a.txt")}, uploads: make(map[string]string)}
+	opts := &Options{Users: []*auth.User{newTestUser(t, "alice", "secret", "/home", false)}, Locks: ls}
+	h := mustFileSystem(t, "", "/work", d, opts)
+	withAuth := func(header map[string]string) map[string]string {
+		header["Authorization"] = "Basic YWxpY2U6c2VjcmV0"
+		return header
+	}
+
+	body := `<?xml version="1.0"?><D:lockinfo xmlns:D="DAV:"><D:lockscope><D:exclusive/></D:lockscope>` +
+		`<D:locktype><D:write/></D:locktype><D:owner>me</D:owner></D:lockinfo>`
+	w := serve(h, "LOCK", "/a.txt", withAuth(map[string]string{"Timeout": "Second-60"}), body)
+	token := strings.Trim(w.Header().Get("Lock-Token"), "<>")
+	if w.Code != http.StatusOK || token == "" {
+		t.Fatalf("lock: status %d, token %s", w.Code, token)
+	}
+	if err = ls.Locked(LockName("/work", "/home/a.txt")); err == nil {
+		t.Error("lock is not named by cloud path under account")
+	}
+	if w = serve(h, http.MethodPut, "/a.txt", withAuth(map[string]string{}), "new"); w.Code != webdav.StatusLocked {
+		t.Errorf("put without token: status %d", w.Code)
+	}
+	// same cloud path of another account is not locked
+	other := mustFileSystem(t, "", "/other", d, opts)
+	if w = serve(other, http.MethodPut, "/a.txt", withAuth(map[string]string{}), "new"); w.Code != http.StatusNoContent {
+		t.Errorf("put of another account: status %d", w.Code)
+	}
+	if w = serve(h, "MOVE", "/a.txt", withAuth(map[string]string{"Destination": "/b.txt"}), ""); w.Code != webdav.StatusLocked {
+		t.Errorf("move without token: status %d", w.Code)
+	}
+	if w = serve(h, http.MethodPut, "/a.txt", withAuth(map[string]string{"If": "(<" + token + ">)"}), "new"); w.Code != http.StatusNoContent {
+		t.Errorf("put with token: status %d", w.Code)
+	}
+	if w = serve(h, "UNLOCK", "/a.txt", withAuth(map[string]string{"Lock-Token": "<" + token + ">"}), ""); w.Code != http.StatusNoContent {
+		t.Errorf("unlock: status %d", w.Code)
+	}
+	if w = serve(h, "MOVE", "/a.txt", withAuth(map[string]string{"Destination": "/b.txt"}), ""); w.Code != http.StatusCreated {
+		t.Errorf("move after unlock: status %d", w.Code)
+	}
+}
@@ -0,0 +1,111 @@
+package webdav
+
+import (
+	"context"
+	"encoding/xml"
+	"io/fs"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/file"
+	"golang.org/x/net/webdav"
+)
+
+// space is cached for quota properties, clients ask for it on each listing
+const spaceTTL = time.Minute
+
+// info serves etag of md5 and mime type of cloud file
+type info struct {
+	pkg.File
+}
+
+func wrapInfo(f fs.FileInfo) fs.FileInfo {
+	if v, ok := f.(pkg.File); ok {
+		return &info{File: v}
+	}
+	return f
+}
+
+func (i *info) ContentType(ctx context.Context) (string, error) {
+	return file.ContentType(i.Name()), nil
+}
+func (i *info) ETag(ctx context.Context) (string, error) {
+	return file.ETag(i.File), nil
+}
+
+// quota returns cached space of the drive, failures are cached as well and the last space is
+// served if any, ok is false when space is unknown
+func (f *CloudFileSystem) quota() (space pkg.Space, ok bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if time.Since(f.spaceAt) < spaceTTL {
+		return f.space, f.spaceOk
+	}
+	f.spaceAt = time.Now()
+	space, err := f.app.Space()
+	if err != nil {
+		log.Println("webdav: get quota failed:", err)
+		return f.space, f.spaceOk
+	}
+	f.space, f.spaceOk = space, true
+	return space, true
+}
+
+// quotaProps returns quota properties of RFC 4331, none when space is unknown
+// so that listing does not fail for it
+func (f *CloudFileSystem) quotaProps() (map[xml.Name]webdav.Property, error) {
+	space, ok := f.quota()
+	if !ok {
+		return nil, nil
+	}
+	used := uint64(0)
+	if space.Capacity > space.Available {
+		used = space.Capacity - space.Available
+	}
+	props := make(map[xml.Name]webdav.Property, 2)
+	for name, value := range map[string]uint64{"quota-available-bytes": space.Available, "quota-used-bytes": used} {
+		n := xml.Name{Space: "DAV:", Local: name}
+		props[n] = webdav.Property{XMLName: n, InnerXML: []byte(strconv.FormatUint(value, 10))}
+	}
+	return props, nil
+}
+
+// preconditionFailed checks If-Match and If-None-Match against current file, which is nil when it does not exist
+func preconditionFailed(r *http.Request, current fs.FileInfo) bool {
+	etag := ""
+	if current != nil {
+		etag = file.ETag(current)
+	}
+	if h := r.Header.Get("If-Match"); h != "" {
+		if current == nil || !matchETag(h, etag, false) {
+			return true
+		}
+	}
+	if h := r.Header.Get("If-None-Match"); h != "" {
+		if current != nil && matchETag(h, etag, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchETag reports whether etag is in the list of header, weak tags only match in weak comparison
+func matchETag(header, etag string, weak bool) bool {
+	for _, v := range strings.Split(header, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" {
+			return true
+		}
+		if weak {
+			v = strings.TrimPrefix(v, "W/")
+		}
+		if v == etag {
+			return true
+		}
+	}
+	return false
+}
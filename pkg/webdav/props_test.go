@@ -0,0 +1,158 @@
+package webdav
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gowsp/cloud189/pkg"
+)
+
+// propDrive serves space and content of memDrive
+type propDrive struct {
+	*memDrive
+	spaceCalls int
+	spaceErr   error
+}
+
+func (d *propDrive) Space() (pkg.Space, error) {
+	d.spaceCalls++
+	if d.spaceErr != nil {
+		return pkg.Space{}, d.spaceErr
+	}
+	return pkg.Space{Capacity: 1000, Available: 600}, nil
+}
+
+type memContent struct {
+	*strings.Reader
+	info fs.FileInfo
+}
+
+func (c *memContent) Stat() (fs.FileInfo, error) { return c.info, nil }
+func (c *memContent) Close() error               { return nil }
+
+func (d *propDrive) Open(name string) (fs.File, error) {
+	info, err := d.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	return &memContent{Reader: strings.NewReader("hello"), info: info}, nil
+}
+
+// md5File knows md5 of its content
+type md5File struct {
+	*memFile
+}
+
+func (f *md5File) FileMD5() string { return "5D41402ABC4B2A76B9719D911017C592" }
+
+func (d *propDrive) Stat(name string) (fs.FileInfo, error) {
+	info, err := d.memDrive.Stat(name)
+	if err != nil || info.IsDir() {
+		return info, err
+	}
+	return &md5File{info.(*memFile)}, nil
+}
+
+func serve(h http.Handler, method, target string, header map[string]string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestProps(t *testing.T) {
+	d := &propDrive{memDrive: newMemDrive("/a.mp4", "/b", "/dir/")}
+	h := mustFileSystem(t, "", "", d, &Options{})
+	body := `<?xml version="1.0"?><D:propfind xmlns:D="DAV:"><D:prop>` +
+		`<D:quota-available-bytes/><D:quota-used-bytes/><D:getetag/><D:getcontenttype/>` +
+		`</D:prop></D:propfind>`
+	for i := 0; i < 2; i++ {
+		w := serve(h, "PROPFIND", "/", map[string]string{"Depth": "1"}, body)
+		if w.Code != http.StatusMultiStatus {
+			t.Fatalf("propfind status %d", w.Code)
+		}
+		res := w.Body.String()
+		for _, want := range []string{
+			">600</D:quota-available-bytes>", ">400</D:quota-used-bytes>",
+			`"5d41402abc4b2a76b9719d911017c592"`, ">video/mp4</D:getcontenttype>",
+			">application/octet-stream</D:getcontenttype>",
+		} {
+			if !strings.Contains(res, want) {
+				t.Errorf("propfind response lacks %s: %s", want, res)
+			}
+		}
+	}
+	if d.spaceCalls != 1 {
+		t.Errorf("space is requested %d times", d.spaceCalls)
+	}
+}
+
+func TestPropsSpaceFailed(t *testing.T) {
+	d := &propDrive{memDrive: newMemDrive("/a.mp4", "/dir/"), spaceErr: errors.New("space failed")}
+	h := mustFileSystem(t, "", "", d, &Options{})
+	body := `<?xml version="1.0"?><D:propfind xmlns:D="DAV:"><D:prop>` +
+		`<D:quota-available-bytes/><D:getcontenttype/></D:prop></D:propfind>`
+	propfind := func() string {
+		w := serve(h, "PROPFIND", "/", map[string]string{"Depth": "1"}, body)
+		if w.Code != http.StatusMultiStatus {
+			t.Fatalf("propfind status %d", w.Code)
+		}
+		return w.Body.String()
+	}
+	for i := 0; i < 2; i++ {
+		res := propfind()
+		if !strings.Contains(res, ">video/mp4</D:getcontenttype>") || strings.Contains(res, ">0</D:quota-available-bytes>") {
+			t.Errorf("listing without quota: %s", res)
+		}
+	}
+	if d.spaceCalls != 1 {
+		t.Errorf("failed space is requested %d times", d.spaceCalls)
+	}
+	// the last space is served when it fails after expiry
+	d.spaceErr = nil
+	h.spaceAt = time.Time{}
+	propfind()
+	d.spaceErr = errors.New("space failed")
+	h.spaceAt = time.Time{}
+	if res := propfind(); !strings.Contains(res, ">600</D:quota-available-bytes>") {
+		t.Errorf("last quota is not served: %s", res)
+	}
+}
+
+func TestConditional(t *testing.T) {
+	d := &propDrive{memDrive: newMemDrive("/a.mp4")}
+	h := mustFileSystem(t, "", "", d, &Options{})
+	etag := `"5d41402abc4b2a76b9719d911017c592"`
+
+	w := serve(h, http.MethodGet, "/a.mp4", nil, "")
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != etag || w.Body.String() != "hello" {
+		t.Errorf("get: status %d, etag %s, body %s", w.Code, w.Header().Get("ETag"), w.Body)
+	}
+	if w = serve(h, http.MethodGet, "/a.mp4", map[string]string{"If-None-Match": etag}, ""); w.Code != http.StatusNotModified {
+		t.Errorf("get if-none-match: status %d", w.Code)
+	}
+	if w = serve(h, http.MethodGet, "/a.mp4", map[string]string{"If-Match": `"other"`}, ""); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("get if-match: status %d", w.Code)
+	}
+	if w = serve(h, http.MethodGet, "/a.mp4", map[string]string{"Range": "bytes=1-2"}, ""); w.Code != http.StatusPartialContent || w.Body.String() != "el" {
+		t.Errorf("get range: status %d, body %s", w.Code, w.Body)
+	}
+
+	if w = serve(h, http.MethodPut, "/a.mp4", map[string]string{"If-None-Match": "*"}, "new"); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("put if-none-match: status %d", w.Code)
+	}
+	if w = serve(h, http.MethodPut, "/a.mp4", map[string]string{"If-Match": `"other"`}, "new"); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("put if-match: status %d", w.Code)
+	}
+	if w = serve(h, http.MethodPut, "/b.mp4", map[string]string{"If-Match": "*"}, "new"); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("put if-match of missing file: status %d", w.Code)
+	}
+}
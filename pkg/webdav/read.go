@@ -1,55 +1,58 @@
 package webdav
 
 import (
+	"encoding/xml"
+	"io"
 	"io/fs"
-	"log"
-	"os"
-	"path/filepath"
-	"sync"
+	"net/http"
 
 	"github.com/gowsp/cloud189/pkg"
 	"golang.org/x/net/webdav"
 )
 
-func newRead(app pkg.Drive, name string) (webdav.File, error) {
-	stat, err := app.Stat(name)
+func newRead(h *CloudFileSystem, name string) (webdav.File, error) {
+	f, err := h.app.Open(name)
 	if err != nil {
 		return nil, err
 	}
-	return &read{app: app, name: name, stat: stat.(pkg.File)}, nil
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &read{fs: h, app: h.app, name: name, stat: stat.(pkg.File), file: f}, nil
 }
 
 var empty = &read{}
 
+// read serves file by ranged stream of drive, nothing is spooled locally
 type read struct {
+	fs   *CloudFileSystem
 	app  pkg.Drive
 	name string
 	stat pkg.File
-	load sync.Once
-	temp *os.File
+	file fs.File
 }
 
-func (r *read) getTemp() *os.File {
-	r.load.Do(func() {
-		dir, name := filepath.Split(r.name)
-		tempDir := os.TempDir() + "/cloud189" + dir
-		_, err := os.Stat(tempDir)
-		if os.IsNotExist(err) {
-			err := os.MkdirAll(tempDir, 0755)
-			if err != nil {
-				log.Println(err)
-				return
-			}
-		}
-		r.app.Download(tempDir, r.name)
-		r.temp, _ = os.OpenFile(tempDir+"/"+name, os.O_CREATE|os.O_RDWR, 0644)
-	})
-	return r.temp
+func (r *read) Seek(offset int64, whence int) (int64, error) {
+	if s, ok := r.file.(io.Seeker); ok {
+		return s.Seek(offset, whence)
+	}
+	return 0, fs.ErrInvalid
+}
+func (r *read) Read(p []byte) (n int, err error) {
+	if r.file == nil {
+		return 0, io.EOF
+	}
+	return r.file.Read(p)
+}
+func (r *read) Write(p []byte) (n int, err error) { return 0, fs.ErrPermission }
+func (r *read) Close() error {
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Close()
 }
-func (r *read) Seek(offset int64, whence int) (int64, error) { return r.getTemp().Seek(offset, whence) }
-func (r *read) Read(p []byte) (n int, err error)             { return r.getTemp().Read(p) }
-func (r *read) Write(p []byte) (n int, err error)            { return r.getTemp().Write(p) }
-func (r *read) Close() error                                 { return nil }
 func (r *read) Readdir(count int) ([]fs.FileInfo, error) {
 	data, err := r.app.ReadDir(r.name)
 	if err != nil {
@@ -57,10 +60,33 @@ func (r *read) Readdir(count int) ([]fs.FileInfo, error) {
 	}
 	files := make([]fs.FileInfo, len(data))
 	for i, v := range data {
-		files[i], _ = v.Info()
+		info, _ := v.Info()
+		files[i] = wrapInfo(info)
 	}
 	return files, err
 }
-func (r *read) Stat() (info fs.FileInfo, err error) {
-	return r.stat, err
+func (r *read) Stat() (fs.FileInfo, error) {
+	if r.stat == nil {
+		return nil, fs.ErrNotExist
+	}
+	return wrapInfo(r.stat), nil
+}
+
+// DeadProps serves quota of the drive on collections
+func (r *read) DeadProps() (map[xml.Name]webdav.Property, error) {
+	if r.stat == nil || !r.stat.IsDir() {
+		return nil, nil
+	}
+	return r.fs.quotaProps()
+}
+
+// Patch rejects all changes, properties are not stored in cloud
+func (r *read) Patch(patches []webdav.Proppatch) ([]webdav.Propstat, error) {
+	stat := webdav.Propstat{Status: http.StatusForbidden}
+	for _, patch := range patches {
+		for _, p := range patch.Props {
+			stat.Props = append(stat.Props, webdav.Property{XMLName: p.XMLName})
+		}
+	}
+	return []webdav.Propstat{stat}, nil
 }
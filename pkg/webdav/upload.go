@@ -1,8 +1,12 @@
 package webdav
 
 import (
+	"errors"
+	"io/fs"
 	"net/http"
 	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/gowsp/cloud189/pkg"
 	"github.com/gowsp/cloud189/pkg/file"
@@ -13,22 +17,61 @@ func (h *CloudFileSystem) handlePut(w http.ResponseWriter, r *http.Request) (sta
 	if err != nil {
 		return status, err
 	}
+	release, status, err := h.confirmLocks(r, reqPath, "")
+	if err != nil {
+		return status, err
+	}
+	defer release()
+	current, err := h.app.Stat(reqPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return pkg.StatusCode(err, http.StatusInternalServerError), err
+	}
+	if err != nil {
+		current = nil
+	}
+	if preconditionFailed(r, current) {
+		return http.StatusPreconditionFailed, nil
+	}
 	if r.ContentLength == 0 {
 		return http.StatusCreated, nil
 	}
 	dir, name := filepath.Split(reqPath)
 	parent, err := h.app.Stat(dir)
 	if err != nil {
-		return http.StatusNotFound, err
+		return pkg.StatusCode(err, http.StatusNotFound), err
 	}
-	f := file.NewWebFile(parent.(pkg.File).Id(), name, r)
-	if copyErr := h.app.UploadFrom(f); copyErr != nil {
-		return http.StatusMethodNotAllowed, copyErr
+	// body is spooled to know its md5, so that data in cloud is reused and chunked body is accepted
+	f, err := h.spool.Create(parent.(pkg.File).Id(), name, r.Body, r.ContentLength)
+	if err != nil {
+		if errors.Is(err, file.ErrSpoolFull) {
+			return http.StatusInsufficientStorage, err
+		}
+		return http.StatusBadRequest, err
+	}
+	defer f.Close()
+	if f.Size() == 0 {
+		return http.StatusCreated, nil
+	}
+	if copyErr := h.app.UploadFrom(pkg.UploadConfig{Conflict: h.conflict}, pkg.WithModTime(f, modTime(r))); copyErr != nil {
+		return pkg.StatusCode(copyErr, http.StatusMethodNotAllowed), copyErr
 	}
 	stat, err := h.app.Stat(reqPath)
 	if err != nil {
 		return http.StatusInternalServerError, err
 	}
-	w.Header().Set("ETag", stat.Name()+stat.ModTime().String())
+	w.Header().Set("ETag", file.ETag(stat))
+	if current != nil {
+		return http.StatusNoContent, nil
+	}
 	return http.StatusCreated, nil
 }
+
+// modTime returns modification time of the body in X-OC-Mtime header as unix seconds,
+// which is sent by ownCloud and Nextcloud clients, zero when absent
+func modTime(r *http.Request) time.Time {
+	sec, err := strconv.ParseFloat(r.Header.Get("X-OC-Mtime"), 64)
+	if err != nil || sec <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(sec*float64(time.Second)))
+}
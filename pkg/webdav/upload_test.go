@@ -0,0 +1,77 @@
+package webdav
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/file"
+)
+
+// uploadDrive keeps uploads of PUT
+type uploadDrive struct {
+	*propDrive
+	uploads map[string]string
+	// modification time of last upload
+	modTime time.Time
+}
+
+func (d *uploadDrive) UploadFrom(cfg pkg.UploadConfig, up pkg.Upload) error {
+	if up.LazyCheck() || up.FileMD5() == "" {
+		return pkg.NewError("upload", "InvalidArgument", "md5 is unknown")
+	}
+	var data strings.Builder
+	for i := 0; i < up.SliceNum(); i++ {
+		io.Copy(&data, up.Part(int64(i)).Data())
+	}
+	name := path.Join(up.ParentId(), up.Name())
+	d.uploads[name] = data.String()
+	d.modTime = time.Time{}
+	if m, ok := up.(interface{ ModTime() time.Time }); ok {
+		d.modTime = m.ModTime()
+	}
+	d.paths[name] = true
+	return nil
+}
+
+func TestPutSpool(t *testing.T) {
+	dir := t.TempDir()
+	spool := &file.Spool{Dir: dir, Limit: 10}
+	d := &uploadDrive{propDrive: &propDrive{memDrive: newMemDrive("/dir/")}, uploads: make(map[string]string)}
+	h := mustFileSystem(t, "", "", d, &Options{Spool: spool})
+
+	req := httptest.NewRequest(http.MethodPut, "/dir/a.txt", strings.NewReader("hello"))
+	req.ContentLength = -1
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated || d.uploads["/dir/a.txt"] != "hello" {
+		t.Errorf("chunked put: status %d, uploads %v", w.Code, d.uploads)
+	}
+	if w = serve(h, http.MethodPut, "/dir/b.txt", nil, "more than ten bytes"); w.Code != http.StatusInsufficientStorage {
+		t.Errorf("put over spool limit: status %d", w.Code)
+	}
+	if spool.Used() != 0 {
+		t.Errorf("spool used %d", spool.Used())
+	}
+}
+
+func TestPutModTime(t *testing.T) {
+	d := &uploadDrive{propDrive: &propDrive{memDrive: newMemDrive("/dir/")}, uploads: make(map[string]string)}
+	h := mustFileSystem(t, "", "", d, &Options{})
+	for header, want := range map[string]time.Time{
+		"":               {},
+		"invalid":        {},
+		"1700000000":     time.Unix(1700000000, 0),
+		"1700000000.500": time.Unix(1700000000, 5e8),
+	} {
+		w := serve(h, http.MethodPut, "/dir/a.txt", map[string]string{"X-OC-Mtime": header}, "hello")
+		if w.Code >= 300 || !d.modTime.Equal(want) {
+			t.Errorf("X-OC-Mtime %q: status %d, mod time %v", header, w.Code, d.modTime)
+		}
+	}
+}
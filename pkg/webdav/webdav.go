@@ -3,25 +3,117 @@ package webdav
 import (
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"path"
+	"strings"
 
 	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/auth"
+	"github.com/gowsp/cloud189/pkg/drive"
+	"github.com/gowsp/cloud189/pkg/file"
 	"golang.org/x/net/webdav"
 )
 
 var errInvalidIfHeader = errors.New("webdav: invalid If header")
 
-func Serve(addr string, client pkg.Drive) {
+// spool of servers without one, in system temp dir without limit
+var defaultSpool = &file.Spool{}
+
+// Options of webdav servers beside those of http server
+type Options struct {
+	// policy of PUT to an existing file, empty keeps server behavior
+	Conflict pkg.ConflictPolicy
+	// keeps bodies of PUT in local temp files before upload, system temp dir without limit when nil
+	Spool *file.Spool
+	// requests are served without auth when it is empty
+	Users []*auth.User
+	// rejects requests changing the drive for all users
+	ReadOnly bool
+	// lock system shared with other servers, locks are kept in memory when nil
+	Locks webdav.LockSystem
+	// accounts of drives by mount, locks are named by account so that servers mounting
+	// an account at different paths agree on them, mount is the account when absent
+	Accounts map[string]string
+}
+
+func Serve(opts *pkg.ServerOptions, dav *Options, client pkg.Drive) error {
+	return ServeMounts(opts, dav, map[string]pkg.Drive{"": client})
+}
+
+// ServeMounts serves each drive under its url prefix, such as drives of different profiles
+func ServeMounts(opts *pkg.ServerOptions, dav *Options, mounts map[string]pkg.Drive) error {
+	if len(dav.Users) == 0 {
+		log.Println("webdav: no user is configured, anyone can access the drive")
+	}
+	if dav.Locks == nil {
+		shared := *dav
+		shared.Locks = webdav.NewMemLS()
+		dav = &shared
+	}
+	mux := http.NewServeMux()
+	for mount, client := range mounts {
+		owner, ok := dav.Accounts[mount]
+		mount = strings.TrimSuffix(mount, "/")
+		if !ok {
+			owner = mount
+		}
+		prefix := strings.TrimSuffix(opts.Prefix, "/") + mount
+		fs, err := NewFileSystem(prefix, owner, client, dav)
+		if err != nil {
+			return err
+		}
+		mux.Handle(prefix+"/", fs)
+		log.Println("webdav serves at", opts.URL()+mount+"/")
+	}
+	return opts.ListenAndServe(mux)
+}
+
+// NewFileSystem serves client of account owner under url prefix
+func NewFileSystem(prefix, owner string, client pkg.Drive, opts *Options) (*CloudFileSystem, error) {
+	locks := opts.Locks
+	if locks == nil {
+		locks = webdav.NewMemLS()
+	}
+	fs := newRootFileSystem(prefix, owner, client, opts, locks, opts.ReadOnly)
+	if len(opts.Users) == 0 {
+		return fs, nil
+	}
+	fs.users = make(map[string]*account, len(opts.Users))
+	fs.secret = newSecret()
+	for _, u := range opts.Users {
+		root := client
+		if path.Clean("/"+u.Root) != "/" {
+			sub, err := drive.Sub(client, u.Root)
+			if err != nil {
+				return nil, fmt.Errorf("root of webdav user %s: %w", u.Name, err)
+			}
+			root = sub
+		}
+		fs.users[u.Name] = &account{User: u, fs: newRootFileSystem(prefix, owner, root, opts, locks, opts.ReadOnly || u.ReadOnly)}
+		fs.digest = fs.digest || u.Digest != ""
+	}
+	return fs, nil
+}
+
+// newRootFileSystem serves client under prefix
+func newRootFileSystem(prefix, owner string, client pkg.Drive, opts *Options, locks webdav.LockSystem, readOnly bool) *CloudFileSystem {
+	spool := opts.Spool
+	if spool == nil {
+		spool = defaultSpool
+	}
 	fs := &CloudFileSystem{
-		app: client,
+		app:      client,
+		Prefix:   prefix,
+		owner:    owner,
+		conflict: opts.Conflict,
+		spool:    spool,
+		readOnly: readOnly,
 	}
 	fs.handler = &webdav.Handler{
+		Prefix:     prefix,
 		FileSystem: fs,
-		LockSystem: webdav.NewMemLS(),
 	}
-	err := http.ListenAndServe(addr, fs)
-	if err != nil {
-		fmt.Println(err)
-	}
-
+	fs.handler.LockSystem = &rootLocks{LockSystem: locks, fs: fs}
+	return fs
 }
@@ -3,10 +3,15 @@ package webui
 import (
 	"fmt"
 	"net/http"
+	"path"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/drive"
 	"github.com/gowsp/cloud189/pkg/file"
+	"github.com/gowsp/cloud189/pkg/webdav"
 )
 
 // handleListFiles 处理文件列表请求
@@ -86,6 +91,10 @@ func (s *Server) handleUpload(c *gin.Context) {
 	}
 	defer src.Close()
 
+	if s.locked(c, path.Join(parentPath, fileHeader.Filename)) {
+		return
+	}
+
 	// 获取父目录信息
 	parent, err := s.app.Stat(parentPath)
 	if err != nil {
@@ -101,9 +110,13 @@ func (s *Server) handleUpload(c *gin.Context) {
 
 	// 创建上传文件对象
 	uploadFile := file.NewWebFile(parent.(pkg.File).Id(), fileHeader.Filename, req)
+	// 浏览器提交的本地文件修改时间(毫秒), 用于 newer 策略
+	if ms, err := strconv.ParseInt(c.PostForm("lastModified"), 10, 64); err == nil && ms > 0 {
+		uploadFile = pkg.WithModTime(uploadFile, time.UnixMilli(ms))
+	}
 
 	// 执行上传
-	err = s.app.UploadFrom(uploadFile)
+	err = s.app.UploadFrom(pkg.UploadConfig{Conflict: s.conflict, Progress: s.progress}, uploadFile)
 	if err != nil {
 		errorResponse(c, 1, fmt.Sprintf("上传文件失败: %v", err))
 		return
@@ -149,6 +162,10 @@ func (s *Server) handleMkdir(c *gin.Context) {
 	}
 	fullPath += req.Name
 
+	if s.locked(c, fullPath) {
+		return
+	}
+
 	// 创建文件夹
 	err := s.app.Mkdir(fullPath)
 	if err != nil {
@@ -162,12 +179,22 @@ func (s *Server) handleMkdir(c *gin.Context) {
 	})
 }
 
+// cloudPath 文件ID为文件名, 与请求参数path中的当前目录组成云盘路径
+func cloudPath(c *gin.Context) string {
+	return path.Join("/", c.Query("path"), c.Param("id"))
+}
+
 // handleDelete 处理删除文件请求
 func (s *Server) handleDelete(c *gin.Context) {
 	fileId := c.Param("id")
+	filePath := cloudPath(c)
+
+	if s.locked(c, filePath) {
+		return
+	}
 
 	// 删除文件
-	err := s.app.Delete(fileId)
+	err := s.app.Delete(pkg.TaskConfig{}, filePath)
 	if err != nil {
 		errorResponse(c, 1, fmt.Sprintf("删除文件失败: %v", err))
 		return
@@ -195,13 +222,10 @@ func (s *Server) handleRename(c *gin.Context) {
 		return
 	}
 
-	// 构建文件路径 - 使用当前路径和文件ID
-	// 这里假设fileId就是文件名，如果需要完整路径，可能需要额外的逻辑
-	var filePath string
-	if currentPath := c.Query("path"); currentPath != "" {
-		filePath = currentPath + "/" + fileId
-	} else {
-		filePath = "/" + fileId
+	filePath := cloudPath(c)
+
+	if s.locked(c, filePath, path.Join(path.Dir(filePath), req.NewName)) {
+		return
 	}
 
 	// 执行重命名
@@ -229,8 +253,12 @@ func (s *Server) handleMove(c *gin.Context) {
 		return
 	}
 
+	if s.locked(c, req.SourcePath, path.Join(req.TargetPath, path.Base(req.SourcePath))) {
+		return
+	}
+
 	// 移动文件
-	err := s.app.Move(req.TargetPath, req.SourcePath)
+	err := s.app.Move(pkg.TaskConfig{}, req.TargetPath, req.SourcePath)
 	if err != nil {
 		errorResponse(c, 1, fmt.Sprintf("移动文件失败: %v", err))
 		return
@@ -296,3 +324,16 @@ func (s *Server) handleSpace(c *gin.Context) {
 		"free":  space.Available,
 	})
 }
+
+// locked 文件被webdav锁定时返回错误
+func (s *Server) locked(c *gin.Context, names ...string) bool {
+	locks := make([]string, len(names))
+	for i, name := range names {
+		locks[i] = webdav.LockName(s.owner, drive.Abs(s.app, name))
+	}
+	if err := s.locks.Locked(locks...); err != nil {
+		errorResponse(c, 1, fmt.Sprintf("文件已被锁定: %v", err))
+		return true
+	}
+	return false
+}
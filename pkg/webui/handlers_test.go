@@ -0,0 +1,130 @@
+package webui
+
+import (
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/webdav"
+	davlock "golang.org/x/net/webdav"
+)
+
+// changeDrive records paths of changes, other methods are not served
+type changeDrive struct {
+	pkg.Drive
+	changed []string
+}
+
+func (d *changeDrive) Delete(cfg pkg.TaskConfig, name ...string) error {
+	d.changed = append(d.changed, name...)
+	return nil
+}
+
+func (d *changeDrive) Rename(oldPath, newName string) error {
+	d.changed = append(d.changed, oldPath)
+	return nil
+}
+
+func TestLockedById(t *testing.T) {
+	locks, err := webdav.OpenFileLS(filepath.Join(t.TempDir(), "locks.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = locks.Create(time.Now(), davlock.LockDetails{Root: webdav.LockName("/work", "/dir/a.txt"), Duration: time.Minute, ZeroDepth: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serve := func(owner, method, target, body string) (*changeDrive, int) {
+		d := &changeDrive{}
+		s := &Server{app: d, owner: owner, locks: locks}
+		engine := gin.New()
+		engine.DELETE("/api/files/:id", s.handleDelete)
+		engine.PUT("/api/files/:id/rename", s.handleRename)
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		var resp Response
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return d, resp.Code
+	}
+	rename := `{"newName":"b.txt"}`
+	tests := []struct {
+		owner, method, target, body string
+		changed                     string
+	}{
+		{"/work", http.MethodDelete, "/api/files/a.txt?path=/dir", "", ""},
+		{"/work", http.MethodPut, "/api/files/a.txt/rename?path=/dir", rename, ""},
+		{"/work", http.MethodDelete, "/api/files/c.txt?path=/dir", "", "/dir/c.txt"},
+		// same path of another account is not locked
+		{"/home", http.MethodDelete, "/api/files/a.txt?path=/dir", "", "/dir/a.txt"},
+		{"/home", http.MethodPut, "/api/files/a.txt/rename?path=/dir", rename, "/dir/a.txt"},
+	}
+	for _, test := range tests {
+		d, code := serve(test.owner, test.method, test.target, test.body)
+		changed := strings.Join(d.changed, ",")
+		if changed != test.changed || (code == 0) != (test.changed != "") {
+			t.Errorf("%s %s of %s: code %d, changed %q, want %q", test.method, test.target, test.owner, code, changed, test.changed)
+		}
+	}
+}
+
+// rootDrive is changeDrive rooted at dir of the whole drive, nothing exists in it
+type rootDrive struct {
+	changeDrive
+	dir string
+}
+
+func (d *rootDrive) Abs(name string) string                { return path.Join(d.dir, path.Clean("/"+name)) }
+func (d *rootDrive) Stat(name string) (fs.FileInfo, error) { return nil, fs.ErrNotExist }
+
+func TestLocksOfRoots(t *testing.T) {
+	locks, err := webdav.OpenFileLS(filepath.Join(t.TempDir(), "locks.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// webdav serves /dir of account work, web servers below serve the account at other roots
+	dav, err := webdav.NewFileSystem("", "work", &rootDrive{dir: "/dir"}, &webdav.Options{Locks: locks})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := `<?xml version="1.0"?><D:lockinfo xmlns:D="DAV:"><D:lockscope><D:exclusive/></D:lockscope>` +
+		`<D:locktype><D:write/></D:locktype></D:lockinfo>`
+	req := httptest.NewRequest("LOCK", "/a.txt", strings.NewReader(body))
+	req.Header.Set("Timeout", "Second-60")
+	w := httptest.NewRecorder()
+	dav.ServeHTTP(w, req)
+	if w.Header().Get("Lock-Token") == "" {
+		t.Fatalf("lock: status %d", w.Code)
+	}
+
+	tests := []struct {
+		owner, root, target string
+		locked              bool
+	}{
+		{"work", "/", "/api/files/a.txt?path=/dir", true},
+		{"work", "/dir", "/api/files/a.txt?path=/", true},
+		{"work", "/other", "/api/files/a.txt?path=/", false},
+		{"home", "/dir", "/api/files/a.txt?path=/", false},
+	}
+	for _, test := range tests {
+		d := &rootDrive{dir: test.root}
+		s := &Server{app: d, owner: test.owner, locks: locks}
+		engine := gin.New()
+		engine.DELETE("/api/files/:id", s.handleDelete)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, test.target, nil))
+		if locked := len(d.changed) == 0; locked != test.locked {
+			t.Errorf("delete %s of %s at %s: changed %v, want locked %v", test.target, test.owner, test.root, d.changed, test.locked)
+		}
+	}
+}
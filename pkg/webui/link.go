@@ -0,0 +1,56 @@
+package webui
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleListLinks 获取分享链接列表
+func (s *Server) handleListLinks(c *gin.Context) {
+	links, err := s.app.Links()
+	if err != nil {
+		errorResponse(c, 1, fmt.Sprintf("获取分享链接失败: %v", err))
+		return
+	}
+
+	success(c, gin.H{
+		"links": links,
+	})
+}
+
+// handleCreateLink 创建分享链接
+func (s *Server) handleCreateLink(c *gin.Context) {
+	var req struct {
+		Path   string `json:"path" binding:"required"`
+		Expire int    `json:"expire"`
+		Code   bool   `json:"code"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, 1, fmt.Sprintf("参数错误: %v", err))
+		return
+	}
+
+	link, err := s.app.CreateLink(req.Path, req.Expire, req.Code)
+	if err != nil {
+		errorResponse(c, 1, fmt.Sprintf("创建分享链接失败: %v", err))
+		return
+	}
+
+	success(c, link)
+}
+
+// handleCancelLink 取消分享
+func (s *Server) handleCancelLink(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := s.app.CancelLink(id); err != nil {
+		errorResponse(c, 1, fmt.Sprintf("取消分享失败: %v", err))
+		return
+	}
+
+	success(c, gin.H{
+		"id": id,
+	})
+}
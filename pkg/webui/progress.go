@@ -0,0 +1,105 @@
+package webui
+
+import (
+	"io"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gowsp/cloud189/pkg"
+)
+
+// progressHub 将传输进度事件广播给所有订阅的浏览器
+type progressHub struct {
+	mu   sync.Mutex
+	subs map[*subscriber]struct{}
+}
+
+func newProgressHub() *progressHub {
+	return &progressHub{subs: make(map[*subscriber]struct{})}
+}
+
+func (h *progressHub) subscribe() *subscriber {
+	sub := &subscriber{ready: make(chan struct{}, 1)}
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+func (h *progressHub) unsubscribe(sub *subscriber) {
+	h.mu.Lock()
+	delete(h.subs, sub)
+	h.mu.Unlock()
+}
+
+// OnProgress 广播事件, 不会因订阅者处理不及时而阻塞传输
+func (h *progressHub) OnProgress(e pkg.ProgressEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs {
+		sub.push(e)
+	}
+}
+
+// subscriber 缓存待推送的事件, 处理不及时时同一传输的中间进度只保留最新一条,
+// 开始、完成及出错事件总会送达
+type subscriber struct {
+	mu     sync.Mutex
+	events []pkg.ProgressEvent
+	// 有待推送事件时可读
+	ready chan struct{}
+}
+
+func intermediate(e pkg.ProgressEvent) bool {
+	return e.Kind == pkg.ProgressBytes || e.Kind == pkg.ProgressPart
+}
+
+func (s *subscriber) push(e pkg.ProgressEvent) {
+	s.mu.Lock()
+	merged := false
+	if intermediate(e) {
+		for i := len(s.events) - 1; i >= 0; i-- {
+			if s.events[i].Id != e.Id {
+				continue
+			}
+			if intermediate(s.events[i]) {
+				s.events[i], merged = e, true
+			}
+			break
+		}
+	}
+	if !merged {
+		s.events = append(s.events, e)
+	}
+	s.mu.Unlock()
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+// pop 取出全部待推送事件
+func (s *subscriber) pop() []pkg.ProgressEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := s.events
+	s.events = nil
+	return events
+}
+
+// handleProgress 以 Server-Sent Events 推送传输进度
+func (s *Server) handleProgress(c *gin.Context) {
+	sub := s.progress.subscribe()
+	defer s.progress.unsubscribe(sub)
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-sub.ready:
+			for _, e := range sub.pop() {
+				c.SSEvent("progress", e)
+			}
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
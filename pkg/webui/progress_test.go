@@ -0,0 +1,43 @@
+package webui
+
+import (
+	"testing"
+
+	"github.com/gowsp/cloud189/pkg"
+)
+
+func TestSlowSubscriber(t *testing.T) {
+	hub := newProgressHub()
+	sub := hub.subscribe()
+	hub.OnProgress(pkg.ProgressEvent{Id: 1, Kind: pkg.ProgressStart})
+	for i := int64(1); i <= 1000; i++ {
+		hub.OnProgress(pkg.ProgressEvent{Id: 1, Kind: pkg.ProgressBytes, Done: i})
+		hub.OnProgress(pkg.ProgressEvent{Id: 2, Kind: pkg.ProgressBytes, Done: i})
+	}
+	hub.OnProgress(pkg.ProgressEvent{Id: 1, Kind: pkg.ProgressFinish, Done: 1000})
+	hub.OnProgress(pkg.ProgressEvent{Id: 2, Kind: pkg.ProgressError, Error: "boom"})
+
+	<-sub.ready
+	events := sub.pop()
+	want := []struct {
+		id   uint64
+		kind pkg.ProgressKind
+		done int64
+	}{
+		{1, pkg.ProgressStart, 0}, {1, pkg.ProgressBytes, 1000}, {2, pkg.ProgressBytes, 1000},
+		{1, pkg.ProgressFinish, 1000}, {2, pkg.ProgressError, 0},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("events %+v", events)
+	}
+	for i, w := range want {
+		if e := events[i]; e.Id != w.id || e.Kind != w.kind || e.Done != w.done {
+			t.Errorf("event %d: %+v, want %+v", i, e, w)
+		}
+	}
+	hub.unsubscribe(sub)
+	hub.OnProgress(pkg.ProgressEvent{Id: 3, Kind: pkg.ProgressStart})
+	if events := sub.pop(); len(events) != 0 {
+		t.Errorf("unsubscribed got %+v", events)
+	}
+}
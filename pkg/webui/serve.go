@@ -2,20 +2,46 @@ package webui
 
 import (
 	"fmt"
-	"log"
 	"net/http"
+	"strings"
 
 	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/webdav"
 )
 
+// Options Web服务器http服务以外的选项
+type Options struct {
+	// 上传文件与云端文件同名时的处理方式, 为空时保持云盘默认行为
+	Conflict pkg.ConflictPolicy
+	// webdav锁, 被锁定的文件拒绝修改, 为空时不检查
+	Locks *webdav.FileLS
+	// 各挂载路径的账号, webdav锁按账号命名, 使不同挂载路径的服务共享同一账号的锁, 缺省以挂载路径作为账号
+	Accounts map[string]string
+}
+
 // Serve 启动Web服务器
-func Serve(port string, app pkg.Drive) {
-	server := NewServer(app)
-	
-	fmt.Printf("Starting web server on port %s\n", port)
-	fmt.Printf("Web interface available at: http://localhost:%s\n", port)
-	
-	if err := http.ListenAndServe(":"+port, server.engine); err != nil {
-		log.Fatal("Failed to start web server:", err)
+func Serve(opts *pkg.ServerOptions, web *Options, app pkg.Drive) error {
+	return ServeMounts(opts, web, map[string]pkg.Drive{"": app})
+}
+
+// ServeMounts 启动Web服务器，按路径前缀挂载多个账号
+func ServeMounts(opts *pkg.ServerOptions, web *Options, mounts map[string]pkg.Drive) error {
+	mux := http.NewServeMux()
+	for mount, app := range mounts {
+		owner, ok := web.Accounts[mount]
+		mount = strings.TrimSuffix(mount, "/")
+		if !ok {
+			owner = mount
+		}
+		prefix := strings.TrimSuffix(opts.Prefix, "/") + mount
+		mux.Handle(prefix+"/", NewMountServer(prefix, owner, app, web).engine)
+		fmt.Printf("Web interface available at: %s%s/\n", opts.URL(), mount)
+	}
+
+	fmt.Printf("Starting web server on %s\n", opts.Addr)
+
+	if err := opts.ListenAndServe(mux); err != nil {
+		return fmt.Errorf("failed to start web server: %w", err)
 	}
-}
\ No newline at end of file
+	return nil
+}
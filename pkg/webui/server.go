@@ -3,22 +3,34 @@ package webui
 import (
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-contrib/sessions/cookie"
 	"github.com/gin-gonic/gin"
 	"github.com/gowsp/cloud189/pkg"
+	"github.com/gowsp/cloud189/pkg/webdav"
 )
 
 // Server Web服务器结构
 type Server struct {
-	app    pkg.Drive
-	engine *gin.Engine
+	app      pkg.Drive
+	engine   *gin.Engine
+	prefix   string
+	owner    string // 所属账号, webdav锁按其区分不同账号
+	conflict pkg.ConflictPolicy
+	locks    *webdav.FileLS
+	progress *progressHub
 }
 
 // NewServer 创建新的Web服务器
-func NewServer(app pkg.Drive) *Server {
+func NewServer(app pkg.Drive, opts *Options) *Server {
+	return NewMountServer("", "", app, opts)
+}
+
+// NewMountServer 创建挂载于指定路径前缀下的Web服务器, owner 为网盘所属账号
+func NewMountServer(prefix, owner string, app pkg.Drive, opts *Options) *Server {
 	gin.SetMode(gin.ReleaseMode)
 	engine := gin.Default()
 
@@ -29,13 +41,18 @@ func NewServer(app pkg.Drive) *Server {
 		HttpOnly: true,
 		Secure:   false,                // 在生产环境中应设置为true（需要HTTPS）
 		SameSite: http.SameSiteLaxMode, // 使用Lax模式支持重定向
-		Path:     "/",                  // 确保cookie在整个站点有效
+		Path:     prefix + "/",         // 确保cookie在整个挂载路径有效
 	})
 	engine.Use(sessions.Sessions("cloud189-session", store))
 
 	server := &Server{
-		app:    app,
-		engine: engine,
+		app:      app,
+		engine:   engine,
+		prefix:   prefix,
+		owner:    owner,
+		conflict: opts.Conflict,
+		locks:    opts.Locks,
+		progress: newProgressHub(),
 	}
 
 	server.setupRoutes()
@@ -45,22 +62,23 @@ func NewServer(app pkg.Drive) *Server {
 // setupRoutes 设置路由
 func (s *Server) setupRoutes() {
 	// 静态文件服务
-	s.engine.Static("/static", "./web/static")
+	root := s.engine.Group(s.prefix)
+	root.Static("/static", "./web/static")
 	s.engine.LoadHTMLGlob("web/templates/*")
 
 	// 登录页面（无需认证）
-	s.engine.GET("/", s.handleLogin)
-	s.engine.GET("/login", s.handleLogin)
+	root.GET("/", s.handleLogin)
+	root.GET("/login", s.handleLogin)
 
 	// 认证API（无需认证）
-	auth := s.engine.Group("/api/auth")
+	auth := root.Group("/api/auth")
 	{
 		auth.POST("/login", s.handleAuthLogin)
 		auth.POST("/logout", s.handleAuthLogout)
 	}
 
 	// 天翼云API（需要web认证）
-	cloud := s.engine.Group("/api/cloud")
+	cloud := root.Group("/api/cloud")
 	cloud.Use(s.authMiddleware())
 	{
 		cloud.POST("/login", s.handleCloudLogin)
@@ -70,7 +88,7 @@ func (s *Server) setupRoutes() {
 	}
 
 	// 需要认证的路由
-	authenticated := s.engine.Group("/")
+	authenticated := root.Group("/")
 	authenticated.Use(s.authMiddleware())
 	{
 		// 仪表盘页面
@@ -82,6 +100,7 @@ func (s *Server) setupRoutes() {
 			api.GET("/files", s.handleListFiles)
 			api.GET("/files/:id", s.handleGetFile)
 			api.POST("/files/upload", s.handleUpload)
+			api.GET("/progress", s.handleProgress)
 			api.GET("/files/:id/download", s.handleDownload)
 			api.POST("/files/mkdir", s.handleMkdir)
 			api.DELETE("/files/:id", s.handleDelete)
@@ -89,6 +108,17 @@ func (s *Server) setupRoutes() {
 			api.POST("/files/move", s.handleMove)
 			api.GET("/search", s.handleSearch)
 			api.GET("/space", s.handleSpace)
+
+			// 回收站
+			api.GET("/trash", s.handleListTrash)
+			api.POST("/trash/restore", s.handleRestoreTrash)
+			api.DELETE("/trash/:id", s.handlePurgeTrash)
+			api.DELETE("/trash", s.handleEmptyTrash)
+
+			// 分享链接
+			api.GET("/links", s.handleListLinks)
+			api.POST("/links", s.handleCreateLink)
+			api.DELETE("/links/:id", s.handleCancelLink)
 		}
 	}
 }
@@ -103,12 +133,13 @@ func (s *Server) handleLogin(c *gin.Context) {
 	// 检查是否已经登录
 	session := sessions.Default(c)
 	if session.Get("authenticated") == true {
-		c.Redirect(http.StatusFound, "/dashboard")
+		c.Redirect(http.StatusFound, s.prefix+"/dashboard")
 		return
 	}
 
 	c.HTML(http.StatusOK, "login.html", gin.H{
 		"title": "系统登录 - 天翼云盘 Web 管理",
+		"base":  s.prefix,
 	})
 }
 
@@ -116,6 +147,7 @@ func (s *Server) handleLogin(c *gin.Context) {
 func (s *Server) handleIndex(c *gin.Context) {
 	c.HTML(http.StatusOK, "index.html", gin.H{
 		"title": "天翼云盘 Web 管理界面",
+		"base":  s.prefix,
 	})
 }
 
@@ -174,7 +206,7 @@ func (s *Server) authMiddleware() gin.HandlerFunc {
 			path := c.FullPath()
 			if c.Request.Header.Get("Content-Type") == "application/json" ||
 				c.Request.Header.Get("Accept") == "application/json" ||
-				(len(path) >= 4 && path[:4] == "/api") {
+				strings.HasPrefix(path, s.prefix+"/api") {
 				c.JSON(http.StatusUnauthorized, Response{
 					Code:    401,
 					Message: "未授权访问，请先登录",
@@ -184,7 +216,7 @@ func (s *Server) authMiddleware() gin.HandlerFunc {
 			}
 
 			// 否则重定向到登录页面
-			c.Redirect(http.StatusFound, "/login")
+			c.Redirect(http.StatusFound, s.prefix+"/login")
 			c.Abort()
 			return
 		}
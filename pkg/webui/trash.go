@@ -0,0 +1,72 @@
+package webui
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleListTrash 获取回收站文件列表
+func (s *Server) handleListTrash(c *gin.Context) {
+	files, err := s.app.Trash()
+	if err != nil {
+		errorResponse(c, 1, fmt.Sprintf("获取回收站列表失败: %v", err))
+		return
+	}
+
+	fileInfos := make([]FileInfo, 0, len(files))
+	for _, file := range files {
+		fileInfos = append(fileInfos, convertFileInfo(file))
+	}
+
+	success(c, gin.H{
+		"files": fileInfos,
+	})
+}
+
+// handleRestoreTrash 还原回收站文件到原路径
+func (s *Server) handleRestoreTrash(c *gin.Context) {
+	var req struct {
+		IDs []string `json:"ids" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, 1, fmt.Sprintf("参数错误: %v", err))
+		return
+	}
+
+	if err := s.app.Restore(req.IDs...); err != nil {
+		errorResponse(c, 1, fmt.Sprintf("还原文件失败: %v", err))
+		return
+	}
+
+	success(c, gin.H{
+		"ids": req.IDs,
+	})
+}
+
+// handlePurgeTrash 彻底删除回收站文件
+func (s *Server) handlePurgeTrash(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := s.app.Purge(id); err != nil {
+		errorResponse(c, 1, fmt.Sprintf("删除回收站文件失败: %v", err))
+		return
+	}
+
+	success(c, gin.H{
+		"id": id,
+	})
+}
+
+// handleEmptyTrash 清空回收站
+func (s *Server) handleEmptyTrash(c *gin.Context) {
+	if err := s.app.EmptyTrash(); err != nil {
+		errorResponse(c, 1, fmt.Sprintf("清空回收站失败: %v", err))
+		return
+	}
+
+	success(c, gin.H{
+		"message": "回收站已清空",
+	})
+}
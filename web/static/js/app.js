@@ -125,7 +125,7 @@ async function loadFiles(path) {
         showLoading();
         currentPath = path;
         
-        const response = await fetch(`/api/files?path=${encodeURIComponent(path)}`);
+        const response = await fetch(`${BASE}/api/files?path=${encodeURIComponent(path)}`);
         const result = await response.json();
         
         if (result.code === 0) {
@@ -347,10 +347,13 @@ function handleFiles(files) {
 
 // 上传文件
 async function uploadFiles(files) {
+    const events = watchProgress(files.length);
     for (let i = 0; i < files.length; i++) {
         const file = files[i];
+        events.current = i;
         await uploadSingleFile(file, i + 1, files.length);
     }
+    events.close();
     
     // 上传完成
     hideUploadModal();
@@ -364,8 +367,9 @@ async function uploadSingleFile(file, current, total) {
         const formData = new FormData();
         formData.append('file', file);
         formData.append('path', currentPath);
+        formData.append('lastModified', file.lastModified);
         
-        const response = await fetch('/api/files/upload', {
+        const response = await fetch(BASE + '/api/files/upload', {
             method: 'POST',
             body: formData
         });
@@ -391,6 +395,27 @@ function updateProgress(percent) {
     document.getElementById('progressText').textContent = Math.round(percent) + '%';
 }
 
+// 订阅服务端推送的上传进度, 文件上传至云盘期间同步更新进度条
+function watchProgress(total) {
+    const source = new EventSource(BASE + '/api/progress');
+    const watcher = {
+        current: 0,
+        close: () => source.close()
+    };
+    source.addEventListener('progress', (e) => {
+        const event = JSON.parse(e.data);
+        if (event.op !== 'upload' || event.total <= 0) return;
+        const fileRatio = Math.min(event.done / event.total, 1);
+        updateProgress((watcher.current + fileRatio) / total * 100);
+        let text = `${event.name} ${Math.round(fileRatio * 100)}% ${formatFileSize(event.speed)}/s`;
+        if (event.eta > 0) {
+            text += ` 剩余 ${Math.ceil(event.eta / 1e9)} 秒`;
+        }
+        document.getElementById('progressText').textContent = text;
+    });
+    return watcher;
+}
+
 // 下载文件
 function downloadFile(id) {
     if (!id && selectedFile) {
@@ -398,7 +423,7 @@ function downloadFile(id) {
     }
     
     if (id) {
-        window.open(`/api/files/${id}/download`, '_blank');
+        window.open(`${BASE}/api/files/${id}/download`, '_blank');
     }
 }
 
@@ -408,7 +433,7 @@ async function createFolder() {
     if (!name) return;
     
     try {
-        const response = await fetch('/api/files/mkdir', {
+        const response = await fetch(BASE + '/api/files/mkdir', {
             method: 'POST',
             headers: {
                 'Content-Type': 'application/json'
@@ -445,7 +470,7 @@ async function renameFile(id) {
     if (!newName || newName === file.name) return;
     
     try {
-        const response = await fetch(`/api/files/${id}/rename?path=${encodeURIComponent(currentPath)}`, {
+        const response = await fetch(`${BASE}/api/files/${id}/rename?path=${encodeURIComponent(currentPath)}`, {
             method: 'PUT',
             headers: {
                 'Content-Type': 'application/json'
@@ -480,7 +505,7 @@ async function deleteFile(id) {
     if (!confirm(`确定要删除 "${file.name}" 吗？`)) return;
     
     try {
-        const response = await fetch(`/api/files/${id}`, {
+        const response = await fetch(`${BASE}/api/files/${id}?path=${encodeURIComponent(currentPath)}`, {
             method: 'DELETE'
         });
         
@@ -508,13 +533,126 @@ function moveFile() {
     showNotification('info', '移动功能正在开发中');
 }
 
+// 创建分享链接
+async function createLink() {
+    if (!selectedFile) return;
+    
+    const expire = prompt('请输入有效天数 (1, 7, 0表示永久):', '7');
+    if (expire === null) return;
+    const code = confirm('是否生成带访问码的私密链接？');
+    const path = currentPath === '/' ? '/' + selectedFile.name : currentPath + '/' + selectedFile.name;
+    
+    try {
+        const response = await fetch(BASE + '/api/links', {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json'
+            },
+            body: JSON.stringify({
+                path: path,
+                expire: parseInt(expire) || 0,
+                code: code
+            })
+        });
+        
+        const result = await response.json();
+        
+        if (result.code === 0) {
+            showNotification('success', '分享链接创建成功');
+            showLinksModal();
+        } else {
+            showNotification('error', result.message);
+        }
+    } catch (error) {
+        showNotification('error', '创建分享链接失败: ' + error.message);
+    }
+}
+
+// 显示分享链接模态框
+async function showLinksModal() {
+    document.getElementById('linksModal').classList.add('show');
+    const container = document.getElementById('linkList');
+    container.innerHTML = '<div class="loading"><i class="fas fa-spinner fa-spin"></i> 加载中...</div>';
+    
+    try {
+        const response = await fetch(BASE + '/api/links');
+        const result = await response.json();
+        
+        if (result.code === 0) {
+            renderLinks(result.data.links || []);
+        } else {
+            showNotification('error', result.message);
+        }
+    } catch (error) {
+        showNotification('error', '获取分享链接失败: ' + error.message);
+    }
+}
+
+// 隐藏分享链接模态框
+function hideLinksModal() {
+    document.getElementById('linksModal').classList.remove('show');
+}
+
+// 渲染分享链接
+function renderLinks(links) {
+    const container = document.getElementById('linkList');
+    
+    if (links.length === 0) {
+        container.innerHTML = '<div class="empty-state">暂无分享链接</div>';
+        return;
+    }
+    
+    const html = links.map(link => {
+        const expire = link.expireTime.startsWith('0001') ? '永久有效' : new Date(link.expireTime).toLocaleString();
+        return `
+        <div class="file-item">
+            <div class="file-icon ${link.isDir ? 'folder' : 'file'}">
+                <i class="fas ${link.isDir ? 'fa-folder' : getFileIcon(link.name)}"></i>
+            </div>
+            <div class="file-info">
+                <div class="file-name">${escapeHtml(link.name)}</div>
+                <div class="file-meta">
+                    <a href="${escapeHtml(link.url)}" target="_blank">${escapeHtml(link.url)}</a>
+                    <span>${link.accessCode ? '访问码: ' + escapeHtml(link.accessCode) : ''}</span>
+                    <span>${expire}</span>
+                </div>
+            </div>
+            <button class="btn btn-danger" onclick="cancelLink('${link.id}')">取消分享</button>
+        </div>
+    `}).join('');
+    
+    container.innerHTML = html;
+}
+
+// 取消分享
+async function cancelLink(id) {
+    if (!confirm('确定要取消该分享吗？')) return;
+    
+    try {
+        const response = await fetch(`${BASE}/api/links/${id}`, {
+            method: 'DELETE'
+        });
+        
+        const result = await response.json();
+        
+        if (result.code === 0) {
+            showNotification('success', '已取消分享');
+            showLinksModal();
+        } else {
+            showNotification('error', result.message);
+        }
+    } catch (error) {
+        showNotification('error', '取消分享失败: ' + error.message);
+    }
+}
+
 // 执行搜索
 async function performSearch() {
     const keyword = document.getElementById('searchInput').value.trim();
     if (!keyword) return;
     
     try {
-        const response = await fetch(`/api/search?keyword=${encodeURIComponent(keyword)}&path=${encodeURIComponent(currentPath)}`);
+        const response = await fetch(`${BASE}/api/search?keyword=${encodeURIComponent(keyword)}&path=${encodeURIComponent(currentPath)}`);
         const result = await response.json();
         
         if (result.code === 0) {
@@ -618,7 +756,7 @@ function hideContextMenu() {
 // 加载存储空间信息
 async function loadSpaceInfo() {
     try {
-        const response = await fetch('/api/space');
+        const response = await fetch(BASE + '/api/space');
         const result = await response.json();
         
         if (result.code === 0) {
@@ -667,7 +805,7 @@ function showNotification(type, message) {
 // 登出函数
 async function logout() {
     try {
-        const response = await fetch('/api/auth/logout', {
+        const response = await fetch(BASE + '/api/auth/logout', {
             method: 'POST',
             headers: {
                 'Content-Type': 'application/json'
@@ -676,7 +814,7 @@ async function logout() {
         
         if (response.ok) {
             // 登出成功，重定向到登录页面
-            window.location.href = '/';
+            window.location.href = BASE + '/';
         } else {
             showNotification('error', '登出失败');
         }
@@ -765,7 +903,7 @@ async function performCloudLogin() {
     showCloudLoginStatus('正在登录...', 'info');
     
     try {
-        const response = await fetch('/api/cloud/login', {
+        const response = await fetch(BASE + '/api/cloud/login', {
             method: 'POST',
             headers: {
                 'Content-Type': 'application/json'
@@ -800,7 +938,7 @@ async function performCloudLogin() {
 // 执行天翼云退出
 async function performCloudLogout() {
     try {
-        const response = await fetch('/api/cloud/logout', {
+        const response = await fetch(BASE + '/api/cloud/logout', {
             method: 'POST',
             headers: {
                 'Content-Type': 'application/json'